@@ -2,16 +2,18 @@ package cache
 
 import (
 	"context"
+	"errors"
+	"io/fs"
 	"log/slog"
 	"slices"
-	"strings"
 	"sync"
+	"time"
 
 	"github.com/Nadim147c/rong/v5/internal/cache"
 	"github.com/Nadim147c/rong/v5/internal/config"
-	"github.com/Nadim147c/rong/v5/internal/ffmpeg"
 	"github.com/Nadim147c/rong/v5/internal/material"
-	"github.com/gabriel-vasile/mimetype"
+	"github.com/Nadim147c/rong/v5/internal/media"
+	"github.com/Nadim147c/rong/v5/internal/scan"
 )
 
 type job struct {
@@ -19,59 +21,54 @@ type job struct {
 	status   string
 }
 
+// process caches the colors of the file of j and returns its final status.
+// update is called with the status of every phase, and is the only way the
+// status of j is changed, as other goroutines read it.
 func (j *job) process(
 	ctx context.Context,
-	update func(),
-	frames int,
-	duration float64,
-) error {
+	update func(status string),
+	opts media.Options,
+) (string, error) {
 	hash, err := cache.Hash(j.filename)
 	if err != nil {
-		return err
+		return "", err
 	}
 
-	mtype, err := mimetype.DetectFile(j.filename)
+	m, err := media.Detect(ctx, j.filename)
 	if err != nil {
-		return err
+		return "", err
 	}
-	isVideo := strings.HasPrefix(mtype.String(), "video")
 
-	if cache.IsCached(hash, isVideo) {
-		return nil
+	key := cache.Variant(hash, opts.Key(m))
+	if cache.IsCached(ctx, key, m.IsVideo()) {
+		return "Already cached", nil
 	}
 
-	// Update state for extraction
-	j.status = "Extracting pixels"
-	update()
-	pixels, err := ffmpeg.GetPixels(ctx, j.filename, frames, duration)
+	update("Extracting pixels")
+	pixels, err := m.Pixels(ctx, opts)
 	if err != nil {
-		return err
+		return "", err
 	}
 
-	// Update state for quantization
-	j.status = "Quantizing colors"
-	update()
-	quantized, err := material.Quantize(ctx, pixels)
+	update("Quantizing colors")
+	quantized, err := material.Quantize(ctx, pixels, opts.Quantize)
 	if err != nil {
-		return err
+		return "", err
 	}
 
-	// Update state for saving
-	j.status = "Saving cache"
-	update()
-	if err := cache.SaveCache(hash, quantized); err != nil {
-		return err
+	update("Saving cache")
+	if err := cache.SaveCache(j.filename, key, quantized); err != nil {
+		return "", err
 	}
 
-	if isVideo {
-		j.status = "Creating preview"
-		update()
-		if _, err := cache.GetPreview(j.filename, hash); err != nil {
-			return err
+	if m.IsVideo() {
+		update("Creating preview")
+		if _, err := cache.GetPreview(ctx, j.filename, hash); err != nil {
+			return "", err
 		}
 	}
 
-	return nil
+	return "Cached", nil
 }
 
 // This does breaks the tea.Model.
@@ -79,9 +76,40 @@ type state struct {
 	done      bool
 	queued    int
 	completed int
+	failed    int
 	active    []job
 }
 
+// Event types reported while caching.
+const (
+	eventQueued  = "queued"
+	eventStarted = "started"
+	eventStatus  = "status"
+	eventDone    = "done"
+	eventFailed  = "failed"
+	eventSummary = "summary"
+)
+
+// event is a single progress change.
+type event struct {
+	Type     string    `json:"type"`
+	Time     time.Time `json:"time"`
+	Filename string    `json:"filename,omitempty"`
+	Status   string    `json:"status,omitempty"`
+	Error    string    `json:"error,omitempty"`
+
+	// Set on summary event.
+	Total     int `json:"total,omitempty"`
+	Succeeded int `json:"succeeded,omitempty"`
+	Failed    int `json:"failed,omitempty"`
+}
+
+// update is sent for every event with a snapshot of the whole progress.
+type update struct {
+	state state
+	event event
+}
+
 func copyJobs(src []*job) []job {
 	dst := make([]job, len(src))
 	for i, v := range src {
@@ -90,44 +118,80 @@ func copyJobs(src []*job) []job {
 	return dst
 }
 
-func cacheRec(ctx context.Context, inputs []string, ch chan<- state) {
+func cacheRec(ctx context.Context, inputs []string, opts media.Options, ch chan<- update) {
 	defer close(ch)
 
-	paths := make(chan string, 100)
-	go func() {
-		_ = find(ctx, inputs, paths)
-		close(paths)
-	}()
-
-	workers := config.Workers.Value()
+	workers := config.Workers.Value(ctx)
 	if workers == 0 {
 		workers = 4
 	}
-	frames := config.FFmpegFrames.Value()
-	if frames == 0 {
-		frames = 4
+	if opts.Frames == 0 {
+		opts.Frames = 4
 	}
-	duration := config.FFmpegDuration.Value().Seconds()
-	if duration == 0 {
-		duration = 5
+	if opts.Duration == 0 {
+		opts.Duration = 5
 	}
 
 	var mu sync.Mutex
 	var wg sync.WaitGroup
 
-	var completed []*job
+	var completed, failed, queued int
 	var active []*job
 
-	update := func() {
+	// send reports e with a snapshot of the progress. The lock is released
+	// before sending, so a slow receiver doesn't block every worker.
+	send := func(e event) {
 		mu.Lock()
-		defer mu.Unlock()
 		var state state
 		state.active = copyJobs(active)
-		state.completed = len(completed)
-		state.queued = len(paths)
-		ch <- state
+		state.completed = completed
+		state.failed = failed
+		state.queued = queued
+		state.done = e.Type == eventSummary
+		mu.Unlock()
+		e.Time = time.Now()
+		ch <- update{state, e}
 	}
 
+	found := make(chan string, 100)
+	go func() {
+		defer close(found)
+		err := scan.Find(ctx, inputs, found)
+		if err == nil || ctx.Err() != nil {
+			return
+		}
+
+		errs := []error{err}
+		if joined, ok := err.(interface{ Unwrap() []error }); ok {
+			errs = joined.Unwrap()
+		}
+		for _, err := range errs {
+			var pathErr *fs.PathError
+			filename := ""
+			if errors.As(err, &pathErr) {
+				filename = pathErr.Path
+			}
+
+			mu.Lock()
+			completed++
+			failed++
+			mu.Unlock()
+			send(event{Type: eventFailed, Filename: filename, Error: err.Error()})
+		}
+	}()
+
+	paths := make(chan string, 100)
+	go func() {
+		defer close(paths)
+		for path := range found {
+			mu.Lock()
+			queued++
+			mu.Unlock()
+			send(event{Type: eventQueued, Filename: path})
+			paths <- path
+		}
+	}()
+
 	for range workers {
 		wg.Go(func() {
 			for path := range paths {
@@ -139,34 +203,53 @@ func cacheRec(ctx context.Context, inputs []string, ch chan<- state) {
 				j := &job{filename: path}
 
 				mu.Lock()
+				queued--
 				active = append(active, j)
 				mu.Unlock()
-				update()
+				send(event{Type: eventStarted, Filename: path})
 
-				err := j.process(ctx, update, frames, duration)
-				if err != nil {
-					slog.Error("Failed to cache", "filename", j.filename, "error", err)
-				} else {
-					slog.Info("Successfully cached", "filename", j.filename)
-				}
+				status, err := j.process(ctx, func(status string) {
+					mu.Lock()
+					j.status = status
+					mu.Unlock()
+					send(event{Type: eventStatus, Filename: j.filename, Status: status})
+				}, opts)
 
 				mu.Lock()
 				active = slices.DeleteFunc(active, func(x *job) bool {
 					return x.filename == j.filename
 				})
-				completed = append(completed, j)
+				completed++
+				if err != nil {
+					failed++
+				}
 				mu.Unlock()
-				update()
+
+				if err != nil {
+					slog.Error("Failed to cache", "filename", j.filename, "error", err)
+					send(event{Type: eventFailed, Filename: path, Error: err.Error()})
+				} else {
+					slog.Info("Successfully cached", "filename", j.filename)
+					send(event{Type: eventDone, Filename: path, Status: status})
+				}
 			}
 		})
 	}
 
 	wg.Wait()
 
-	var state state
-	state.done = true
-	state.active = copyJobs(active)
-	state.completed = len(completed)
-	state.queued = len(paths)
-	ch <- state
+	// Drain paths left behind by cancelled workers, so the scanner can exit.
+	for range paths {
+	}
+
+	mu.Lock()
+	summary := event{
+		Type:      eventSummary,
+		Total:     completed + queued,
+		Succeeded: completed - failed,
+		Failed:    failed,
+	}
+	mu.Unlock()
+
+	send(summary)
 }
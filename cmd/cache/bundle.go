@@ -0,0 +1,97 @@
+package cache
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/Nadim147c/rong/v5/internal/cache"
+	"github.com/Nadim147c/rong/v5/internal/scan"
+	"github.com/google/renameio/v2"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	Command.AddCommand(Export, Import)
+}
+
+// Export is the cache export command.
+var Export = &cobra.Command{
+	Use:   "export <file.tar.zst> [path|key...]",
+	Short: "Export cached colors and previews to a bundle",
+	Example: `
+# Export the whole cache
+rong cache export wallpapers.tar.zst
+
+# Export the cache of some wallpapers
+rong cache export wallpapers.tar.zst ~/Pictures/Wallpapers/*.png
+  `,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		file, err := renameio.NewPendingFile(args[0])
+		if err != nil {
+			return err
+		}
+		defer file.Cleanup()
+
+		n, err := cache.Export(file, args[1:])
+		if err != nil {
+			return err
+		}
+		if err := file.CloseAtomicallyReplace(); err != nil {
+			return err
+		}
+
+		fmt.Fprintf(cmd.OutOrStdout(), "Exported %d entries to %s\n", n, args[0])
+		return nil
+	},
+}
+
+// Import is the cache import command.
+var Import = &cobra.Command{
+	Use:   "import <file.tar.zst> [path...]",
+	Short: "Import cached colors and previews from a bundle",
+	Long: `Import cached colors and previews from a bundle.
+
+Entries are keyed by content, so they are found when the same media is used
+from any path. When paths are given, only entries of images and videos found in
+them are imported.`,
+	Example: `
+# Import everything
+rong cache import wallpapers.tar.zst
+
+# Import entries of local wallpapers only
+rong cache import wallpapers.tar.zst ~/Pictures/Wallpapers
+  `,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		file, err := os.Open(args[0])
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+
+		var locals []string
+		if len(args) > 1 {
+			found := make(chan string, 100)
+			go func() {
+				_ = scan.Find(cmd.Context(), args[1:], found)
+				close(found)
+			}()
+			for path := range found {
+				locals = append(locals, path)
+			}
+			if len(locals) == 0 {
+				return fmt.Errorf("no image or video found in %v", args[1:])
+			}
+		}
+
+		result, err := cache.Import(file, locals)
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintf(cmd.OutOrStdout(), "Imported %d entries (%d matched local files, %d skipped)\n",
+			result.Imported, result.Matched, result.Skipped)
+		return nil
+	},
+}
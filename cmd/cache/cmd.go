@@ -2,19 +2,26 @@ package cache
 
 import (
 	"context"
-	"log/slog"
-	"sync"
+	"errors"
+	"fmt"
 
 	"github.com/Nadim147c/rong/v5/internal/config"
-	ilog "github.com/Nadim147c/rong/v5/internal/log"
-	tea "github.com/charmbracelet/bubbletea"
+	"github.com/Nadim147c/rong/v5/internal/config/enums"
+	"github.com/Nadim147c/rong/v5/internal/media"
 	"github.com/spf13/cobra"
 )
 
 func init() {
 	config.Workers.RegisterFlag(Command.Flags())
+	config.CacheProgress.RegisterFlag(Command.Flags())
+	config.CacheAutoPrune.RegisterFlag(Command.Flags())
+	config.CacheMaxAge.RegisterFlag(Command.Flags())
+	config.CacheMaxSize.RegisterFlag(Command.Flags())
 }
 
+// ErrFailed means some of the files could not be cached.
+var ErrFailed = errors.New("failed to cache")
+
 // Command is cache command.
 var Command = &cobra.Command{
 	Use:   "cache <path...>",
@@ -31,31 +38,40 @@ rong cache path/to/*.png
 
 # Recursively cache all image and video in a directory
 rong cache path/to/directory
+
+# Print progress as line-delimited JSON
+rong cache --progress json path/to/directory | jq
   `,
 	Args: cobra.MinimumNArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		ctx, cancel := context.WithCancel(cmd.Context())
+		defer cancel()
+
+		opts, err := media.GetOptions(ctx)
+		if err != nil {
+			return err
+		}
 
-		states := make(chan state)
-		model := newModel(cancel)
-		p := tea.NewProgram(model)
+		updates := make(chan update)
+		go cacheRec(ctx, args, opts, updates)
 
-		writer := newTeaWriter(p)
-		ilog.SetWriter(writer)
+		var summary event
+		switch progressMode(ctx) {
+		case enums.ProgressTui:
+			summary = reportTea(cancel, updates)
+		case enums.ProgressJson:
+			summary = reportJSON(cmd.OutOrStdout(), updates)
+		case enums.ProgressAuto, enums.ProgressPlain:
+			summary = reportPlain(cmd.OutOrStdout(), updates)
+		}
 
-		var wg sync.WaitGroup
+		if cmd.Context().Err() == nil {
+			autoPrune(cmd.Context())
+		}
 
-		wg.Go(func() {
-			_, err := p.Run()
-			if err != nil {
-				slog.Info("Tui program failed", "error", err)
-			}
-		})
-		wg.Go(func() { cacheRec(ctx, args, states) })
-		for state := range states {
-			p.Send(state)
+		if summary.Failed > 0 {
+			return fmt.Errorf("%w %d of %d files", ErrFailed, summary.Failed, summary.Total)
 		}
-		wg.Wait()
-		return nil
+		return cmd.Context().Err()
 	},
 }
@@ -0,0 +1,140 @@
+package cache
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Nadim147c/material/v3/color"
+	"github.com/Nadim147c/rong/v5/internal/cache"
+	"github.com/charmbracelet/x/term"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	List.Flags().BoolP("json", "j", false, "Output entries as JSON")
+	Show.Flags().BoolP("json", "j", false, "Output entry as JSON")
+	Command.AddCommand(List, Show)
+}
+
+// List is the cache list command.
+var List = &cobra.Command{
+	Use:   "list",
+	Short: "List cached images and videos",
+	Example: `
+# Show cached media with their colors
+rong cache list
+
+# Get sources of cached media
+rong cache list --json | jq -r '.[].source'
+  `,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		index, err := cache.Index()
+		if err != nil {
+			return err
+		}
+
+		asJSON, err := cmd.Flags().GetBool("json")
+		if err != nil {
+			return err
+		}
+
+		w := cmd.OutOrStdout()
+		if asJSON {
+			return json.NewEncoder(w).Encode(index)
+		}
+
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "KEY\tSIZE\tCACHED\tSOURCE\tCOLORS")
+		for _, meta := range index {
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n",
+				meta.Key[:min(12, len(meta.Key))],
+				formatSize(meta.Size),
+				meta.CachedAt.Format(time.DateTime),
+				meta.Source,
+				swatches(w, meta.Colors),
+			)
+		}
+		return tw.Flush()
+	},
+}
+
+// Show is the cache show command.
+var Show = &cobra.Command{
+	Use:   "show <path|key>",
+	Short: "Show the cache entry of an image, video or cache key",
+	Example: `
+# Show cache entry of a wallpaper
+rong cache show path/to/image.webp
+
+# Show cache entry by a key prefix from 'rong cache list'
+rong cache show 3f9a1c
+  `,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		meta, err := cache.Show(args[0])
+		if err != nil {
+			return err
+		}
+
+		asJSON, err := cmd.Flags().GetBool("json")
+		if err != nil {
+			return err
+		}
+
+		w := cmd.OutOrStdout()
+		if asJSON {
+			return json.NewEncoder(w).Encode(meta)
+		}
+
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintf(tw, "Key:\t%s\n", meta.Key)
+		fmt.Fprintf(tw, "Source:\t%s\n", meta.Source)
+		fmt.Fprintf(tw, "Type:\t%s\n", meta.Mime)
+		fmt.Fprintf(tw, "Size:\t%s\n", formatSize(meta.Size))
+		fmt.Fprintf(tw, "Modified:\t%s\n", meta.ModTime.Format(time.DateTime))
+		fmt.Fprintf(tw, "Cached:\t%s\n", meta.CachedAt.Format(time.DateTime))
+		if meta.Preview != "" {
+			fmt.Fprintf(tw, "Preview:\t%s\n", meta.Preview)
+		}
+		fmt.Fprintln(tw, "Colors:")
+		for _, c := range meta.Colors {
+			if isTerminal(w) {
+				fmt.Fprintf(tw, "\t%s %s\n", c.AnsiBg("   "), c.HexRGB())
+			} else {
+				fmt.Fprintf(tw, "\t%s\n", c.HexRGB())
+			}
+		}
+		return tw.Flush()
+	},
+}
+
+// swatches renders colors as truecolor blocks when w is a terminal and as hex
+// codes otherwise.
+func swatches(w io.Writer, colors []color.ARGB) string {
+	tty := isTerminal(w)
+
+	parts := make([]string, len(colors))
+	for i, c := range colors {
+		if tty {
+			parts[i] = c.AnsiBg("   ")
+		} else {
+			parts[i] = c.HexRGB()
+		}
+	}
+	if tty {
+		return strings.Join(parts, "")
+	}
+	return strings.Join(parts, " ")
+}
+
+// isTerminal reports whether w writes to a terminal.
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	return ok && term.IsTerminal(f.Fd())
+}
@@ -0,0 +1,94 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"sync"
+
+	"github.com/Nadim147c/rong/v5/internal/config"
+	"github.com/Nadim147c/rong/v5/internal/config/enums"
+	ilog "github.com/Nadim147c/rong/v5/internal/log"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/term"
+)
+
+// progressMode returns the configured progress output. The tui is only used
+// automatically when both stdin and stdout are terminals.
+func progressMode(ctx context.Context) enums.Progress {
+	mode := config.CacheProgress.Value(ctx)
+	if mode != enums.ProgressAuto {
+		return mode
+	}
+	if term.IsTerminal(os.Stdin.Fd()) && term.IsTerminal(os.Stdout.Fd()) {
+		return enums.ProgressTui
+	}
+	return enums.ProgressPlain
+}
+
+// reportTea shows the progress in a bubbletea program and returns the summary.
+func reportTea(cancel context.CancelFunc, updates <-chan update) event {
+	model := newModel(cancel)
+	p := tea.NewProgram(model)
+
+	writer := newTeaWriter(p)
+	ilog.SetWriter(writer)
+	defer ilog.SetWriter(os.Stderr)
+
+	var wg sync.WaitGroup
+	wg.Go(func() {
+		_, err := p.Run()
+		if err != nil {
+			slog.Info("Tui program failed", "error", err)
+		}
+	})
+
+	var summary event
+	for u := range updates {
+		if u.event.Type == eventSummary {
+			summary = u.event
+		}
+		p.Send(u)
+	}
+	wg.Wait()
+
+	return summary
+}
+
+// reportPlain prints one line for every processed file and returns the
+// summary.
+func reportPlain(w io.Writer, updates <-chan update) event {
+	var summary event
+	for u := range updates {
+		e := u.event
+		switch e.Type {
+		case eventDone:
+			fmt.Fprintf(w, "%s\t%s\n", e.Status, e.Filename)
+		case eventFailed:
+			fmt.Fprintf(w, "Failed\t%s\t%s\n", e.Filename, e.Error)
+		case eventSummary:
+			summary = e
+			fmt.Fprintf(w, "Done! Processed %d files, %d failed\n", e.Total, e.Failed)
+		}
+	}
+	return summary
+}
+
+// reportJSON writes every event as a line of JSON and returns the summary.
+func reportJSON(w io.Writer, updates <-chan update) event {
+	enc := json.NewEncoder(w)
+
+	var summary event
+	for u := range updates {
+		if u.event.Type == eventSummary {
+			summary = u.event
+		}
+		if err := enc.Encode(u.event); err != nil {
+			slog.Error("Failed to encode progress", "error", err)
+		}
+	}
+	return summary
+}
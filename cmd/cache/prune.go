@@ -0,0 +1,95 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+
+	"github.com/Nadim147c/rong/v5/internal/cache"
+	"github.com/Nadim147c/rong/v5/internal/config"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	Prune.Flags().BoolP("dry-run", "d", false, "Print entries to prune without removing them")
+	config.CacheMaxAge.RegisterFlag(Prune.Flags())
+	config.CacheMaxSize.RegisterFlag(Prune.Flags())
+	Command.AddCommand(Prune)
+}
+
+// Prune is the cache prune command.
+var Prune = &cobra.Command{
+	Use:   "prune",
+	Short: "Remove orphaned, unused and excess cache entries",
+	Example: `
+# Show what would be removed
+rong cache prune --dry-run
+
+# Remove entries unused for 30 days and keep the cache under 500MiB
+rong cache prune --cache.max-age 30d --cache.max-size 500M
+  `,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		dryRun, err := cmd.Flags().GetBool("dry-run")
+		if err != nil {
+			return err
+		}
+		pruned, err := prune(cmd.Context(), dryRun)
+		reportPrune(cmd.OutOrStdout(), pruned, dryRun)
+		return err
+	},
+}
+
+// prune prunes the cache with the configured limits.
+func prune(ctx context.Context, dryRun bool) ([]cache.Pruned, error) {
+	return cache.Prune(cache.PruneOptions{
+		MaxAge:  config.CacheMaxAge.Value(ctx),
+		MaxSize: config.CacheMaxSize.Value(ctx),
+		DryRun:  dryRun,
+	})
+}
+
+// reportPrune prints one line for every pruned entry followed by a summary.
+func reportPrune(w io.Writer, pruned []cache.Pruned, dryRun bool) {
+	var total int64
+	for _, entry := range pruned {
+		total += entry.Size
+		fmt.Fprintf(w, "%s\t%s\t%s\n", entry.Reason, formatSize(entry.Size), entry.Key)
+	}
+
+	verb := "Removed"
+	if dryRun {
+		verb = "Would remove"
+	}
+	fmt.Fprintf(w, "%s %d entries, %s\n", verb, len(pruned), formatSize(total))
+}
+
+// autoPrune prunes the cache after caching when cache.auto-prune is enabled.
+func autoPrune(ctx context.Context) {
+	if !config.CacheAutoPrune.Value(ctx) {
+		return
+	}
+
+	pruned, err := prune(ctx, false)
+	if err != nil {
+		slog.Warn("Failed to prune cache", "error", err)
+	}
+	for _, entry := range pruned {
+		slog.Info("Pruned cache entry", "key", entry.Key, "reason", entry.Reason, "size", entry.Size)
+	}
+}
+
+// formatSize formats size in bytes with binary units.
+func formatSize(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%dB", size)
+	}
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f%ciB", float64(size)/float64(div), "KMGTPE"[exp])
+}
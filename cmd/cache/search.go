@@ -0,0 +1,117 @@
+package cache
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/Nadim147c/material/v3/color"
+	"github.com/Nadim147c/rong/v5/internal/cache"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	Search.Flags().String("color", "", "Rank media by how strongly they feature this color")
+	Search.Flags().Int("limit", 20, "Maximum number of results (0 for all)")
+	Search.Flags().String("hue", "", "Only count colors in this hue range (e.g. 20-60 or 330-30)")
+	Search.Flags().String("chroma", "", "Only count colors in this chroma range (e.g. 40-)")
+	Search.Flags().BoolP("json", "j", false, "Output results as JSON")
+	Command.AddCommand(Search)
+}
+
+// ErrNoQuery means search got neither a color nor a range.
+var ErrNoQuery = errors.New("one of --color, --hue or --chroma is required")
+
+// Search is the cache search command.
+var Search = &cobra.Command{
+	Use:   "search",
+	Short: "Search cached images and videos by color",
+	Example: `
+# Pick a wallpaper that features an orange color
+rong cache search --color '#d08770' | fzf
+
+# Media with strongly saturated blues
+rong cache search --hue 230-270 --chroma 50-
+
+# Get scores as JSON
+rong cache search --color '#88c0d0' --limit 5 --json | jq
+  `,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		flags := cmd.Flags()
+		limit, err := flags.GetInt("limit")
+		if err != nil {
+			return err
+		}
+		asJSON, err := flags.GetBool("json")
+		if err != nil {
+			return err
+		}
+
+		hex, err := flags.GetString("color")
+		if err != nil {
+			return err
+		}
+		hue, err := flags.GetString("hue")
+		if err != nil {
+			return err
+		}
+		chroma, err := flags.GetString("chroma")
+		if err != nil {
+			return err
+		}
+
+		opts := cache.SearchOptions{Limit: limit}
+		if hex != "" {
+			c, err := color.ARGBFromHex(hex)
+			if err != nil {
+				return fmt.Errorf("invalid color %q: %w", hex, err)
+			}
+			opts.Color = c
+		}
+
+		if hue != "" {
+			r, err := cache.ParseRange(hue, 0, 360)
+			if err != nil {
+				return err
+			}
+			opts.Hue = &r
+		}
+
+		if chroma != "" {
+			r, err := cache.ParseRange(chroma, 0, 200)
+			if err != nil {
+				return err
+			}
+			opts.Chroma = &r
+		}
+
+		if opts.Color.Alpha() == 0 && opts.Hue == nil && opts.Chroma == nil {
+			return ErrNoQuery
+		}
+
+		matches, err := cache.Search(opts)
+		if err != nil {
+			return err
+		}
+
+		w := cmd.OutOrStdout()
+		if asJSON {
+			return json.NewEncoder(w).Encode(matches)
+		}
+
+		for _, match := range matches {
+			fmt.Fprintln(w, sourceOrKey(match.Meta))
+		}
+		return nil
+	},
+}
+
+// sourceOrKey returns the source of meta, or its key when it has no local
+// source, like entries imported from a bundle.
+func sourceOrKey(meta cache.Meta) string {
+	if meta.Source == "" {
+		return meta.Key
+	}
+	return meta.Source
+}
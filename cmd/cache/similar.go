@@ -0,0 +1,61 @@
+package cache
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/Nadim147c/rong/v5/internal/cache"
+	"github.com/Nadim147c/rong/v5/internal/config"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	Similar.Flags().Int("limit", 10, "Maximum number of results (0 for all)")
+	Similar.Flags().BoolP("json", "j", false, "Output results as JSON")
+	config.Workers.RegisterFlag(Similar.Flags())
+	Command.AddCommand(Similar)
+}
+
+// Similar is the cache similar command.
+var Similar = &cobra.Command{
+	Use:   "similar <path|key>",
+	Short: "Find cached images and videos with a similar palette",
+	Long: `Find cached images and videos with a palette similar to the one of a file
+or cache entry. Files that are not cached yet are quantized and cached first.`,
+	Example: `
+# Wallpapers that feel like the current one
+rong cache similar "$(jq -r .filename ~/.local/state/rong/state.json)"
+
+# Wallpapers like a new download, which is cached first
+rong cache similar ~/Downloads/new.jpg
+
+# Get distances as JSON
+rong cache similar path/to/image.webp --limit 5 --json | jq
+  `,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		limit, err := cmd.Flags().GetInt("limit")
+		if err != nil {
+			return err
+		}
+		asJSON, err := cmd.Flags().GetBool("json")
+		if err != nil {
+			return err
+		}
+
+		neighbors, err := cache.Similar(cmd.Context(), args[0], limit, config.Workers.Value(cmd.Context()))
+		if err != nil {
+			return err
+		}
+
+		w := cmd.OutOrStdout()
+		if asJSON {
+			return json.NewEncoder(w).Encode(neighbors)
+		}
+
+		for _, neighbor := range neighbors {
+			fmt.Fprintln(w, sourceOrKey(neighbor.Meta))
+		}
+		return nil
+	},
+}
@@ -29,6 +29,7 @@ type model struct {
 
 	active    []job
 	completed int
+	failed    int
 	queued    int
 
 	progress progress.Model
@@ -114,19 +115,20 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.exit()
 			return m, tea.Quit
 		}
-	case state:
+	case update:
 		var cmds []tea.Cmd
 
-		m.completed = msg.completed
-		m.active = msg.active
-		m.queued = msg.queued
+		m.completed = msg.state.completed
+		m.failed = msg.state.failed
+		m.active = msg.state.active
+		m.queued = msg.state.queued
 
 		completed := m.completed
 		total := completed + len(m.active) + m.queued
 		perc := float64(completed) / float64(total)
 		cmds = append(cmds, m.progress.SetPercent(perc))
 
-		if msg.done {
+		if msg.state.done {
 			m.done = true
 			cmds = append(cmds, tea.Quit)
 		}
@@ -146,6 +148,9 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 func (m model) View() string {
 	if m.done {
+		if m.failed > 0 {
+			return fmt.Sprintf("Done! Processed %d files, %d failed\n", m.completed, m.failed)
+		}
 		return fmt.Sprintf("Done! Processed %d files\n", m.completed)
 	}
 
@@ -1,7 +1,9 @@
 package color
 
 import (
+	"context"
 	"encoding/json"
+	"io"
 	"log/slog"
 	"strings"
 
@@ -11,6 +13,7 @@ import (
 	"github.com/Nadim147c/rong/v5/internal/base16"
 	"github.com/Nadim147c/rong/v5/internal/config"
 	"github.com/Nadim147c/rong/v5/internal/config/enums"
+	"github.com/Nadim147c/rong/v5/internal/daemon"
 	"github.com/Nadim147c/rong/v5/internal/material"
 	"github.com/Nadim147c/rong/v5/internal/models"
 	"github.com/Nadim147c/rong/v5/internal/templates"
@@ -43,72 +46,82 @@ rong color green --dry-run --json | jq
 	RunE: func(cmd *cobra.Command, args []string) error {
 		ctx := cmd.Context()
 
-		name := strings.ToLower(args[0])
-		source, ok := Names[name]
-		if !ok {
-			src, err := color.ARGBFromHex(name)
-			if err != nil {
-				return err
-			}
-			source = src
+		if ok, err := daemon.Forward(ctx, cmd); ok {
+			return err
 		}
 
-		slog.Info("Generating color", "from", source)
+		return Generate(ctx, cmd.OutOrStdout(), args[0])
+	},
+}
 
-		primary := palettes.NewFromARGB(source)
+// Generate generates colors from a color name or hex value and executes
+// templates. Output requested by flags (json, inline template) is written to w.
+func Generate(ctx context.Context, w io.Writer, name string) error {
+	name = strings.ToLower(name)
+	source, ok := Names[name]
+	if !ok {
+		src, err := color.ARGBFromHex(name)
+		if err != nil {
+			return err
+		}
+		source = src
+	}
 
-		cfg := material.GetConfig()
+	slog.Info("Generating color", "from", source)
 
-		scheme := dynamic.NewDynamicScheme(source.ToHct(),
-			cfg.Variant, cfg.Constrast, cfg.Dark,
-			cfg.Platform, cfg.Version, primary,
-			nil, nil, nil, nil, nil,
-		)
+	primary := palettes.NewFromARGB(source)
 
-		dcs := scheme.ToColorMap()
+	cfg := material.GetConfig(ctx)
 
-		colorMap := map[string]color.ARGB{}
-		for key, value := range dcs {
-			if value != nil {
-				colorMap[key] = value.GetArgb(scheme)
-			}
-		}
+	scheme := dynamic.NewDynamicScheme(source.ToHct(),
+		cfg.Variant, cfg.Constrast, cfg.Dark,
+		cfg.Platform, cfg.Version, primary,
+		nil, nil, nil, nil, nil,
+	)
 
-		customs, err := material.GenerateCustomColors(colorMap["primary"])
-		if err != nil {
-			return err
+	dcs := scheme.ToColorMap()
+
+	colorMap := map[string]color.ARGB{}
+	for key, value := range dcs {
+		if value != nil {
+			colorMap[key] = value.GetArgb(scheme)
 		}
+	}
 
-		// dynamic base16 generation is not possible with single source color
-		config.Base16Method.SetValue(enums.Base16MethodStatic)
-		based := base16.Generate(colorMap, material.Quantized{})
-		output := models.NewOutput("", based, colorMap, customs)
+	customs, err := material.GenerateCustomColors(ctx, colorMap["primary"])
+	if err != nil {
+		return err
+	}
 
-		if config.JSON.Value() {
-			err := json.NewEncoder(cmd.OutOrStdout()).Encode(output)
-			if err != nil {
-				slog.Error("Failed to encode output", "error", err)
-			}
-		}
+	// dynamic base16 generation is not possible with single source color
+	config.Base16Method.SetValue(ctx, enums.Base16MethodStatic)
+	based := base16.Generate(ctx, colorMap, material.Quantized{})
+	output := models.NewOutput(ctx, "", based, colorMap, customs)
 
-		if config.SimpleJSON.Value() {
-			err := models.WriteSimpleJSON(cmd.OutOrStdout(), output)
-			if err != nil {
-				slog.Error("Failed to encode output", "error", err)
-			}
+	if config.JSON.Value(ctx) {
+		err := json.NewEncoder(w).Encode(output)
+		if err != nil {
+			slog.Error("Failed to encode output", "error", err)
 		}
+	}
 
-		if tmpl := config.Template.Value(); tmpl != "" {
-			err := templates.ExecuteInline(tmpl, output, cmd.OutOrStdout())
-			if err != nil {
-				slog.Error("Failed to execute inline template", "error", err)
-			}
+	if config.SimpleJSON.Value(ctx) {
+		err := models.WriteSimpleJSON(w, output)
+		if err != nil {
+			slog.Error("Failed to encode output", "error", err)
 		}
+	}
 
-		if config.DryRun.Value() {
-			return nil
+	if tmpl := config.Template.Value(ctx); tmpl != "" {
+		err := templates.ExecuteInline(tmpl, output, w)
+		if err != nil {
+			slog.Error("Failed to execute inline template", "error", err)
 		}
+	}
 
-		return templates.Execute(ctx, output)
-	},
+	if config.DryRun.Value(ctx) {
+		return nil
+	}
+
+	return templates.Execute(ctx, output)
 }
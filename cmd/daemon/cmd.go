@@ -0,0 +1,119 @@
+package daemon
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+
+	"github.com/Nadim147c/rong/v5/cmd/color"
+	"github.com/Nadim147c/rong/v5/cmd/image"
+	"github.com/Nadim147c/rong/v5/cmd/regen"
+	"github.com/Nadim147c/rong/v5/cmd/video"
+	"github.com/Nadim147c/rong/v5/internal/cache"
+	"github.com/Nadim147c/rong/v5/internal/config"
+	idaemon "github.com/Nadim147c/rong/v5/internal/daemon"
+	"github.com/Nadim147c/rong/v5/internal/media"
+	"github.com/spf13/cobra"
+)
+
+// Command is the daemon command.
+var Command = &cobra.Command{
+	Use:   "daemon",
+	Short: "Serve generation requests from memory for faster regeneration",
+	Long: `Run rong in the background and keep parsed templates and quantized colors in
+memory. While the daemon is running, the image, video, color and regen commands
+forward their arguments to it instead of doing the work themselves. Use
+--no-daemon to run a command locally.`,
+	Example: `
+# Start the daemon
+rong daemon &
+
+# Forwarded to the running daemon
+rong image path/to/image.png
+
+# Run locally even if the daemon is running
+rong image --no-daemon path/to/image.png
+  `,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		ln, err := idaemon.Listen()
+		if err != nil {
+			return fmt.Errorf("failed to listen on socket: %w", err)
+		}
+		defer ln.Close()
+
+		cache.KeepInMemory()
+
+		slog.Info("Daemon is listening", "socket", idaemon.SocketPath)
+		return idaemon.Serve(cmd.Context(), ln, handler(cmd.Root()))
+	},
+}
+
+// handler runs requests with the same command definitions the cli uses. Each
+// request gets its own configuration store built from defaults, the config file
+// and the request flags, and its own flag set writing to that store, so
+// requests run concurrently. Template outputs are still written one request at
+// a time by templates.Execute.
+func handler(root *cobra.Command) idaemon.Handler {
+	// Flag sets of commands are only read here, before requests are served,
+	// as cobra merges and sorts them lazily.
+	commands := map[string]*cobra.Command{}
+	flags := map[string][]config.Flag{}
+	for _, name := range idaemon.Commands {
+		cmd, _, err := root.Find([]string{name})
+		if err != nil {
+			continue
+		}
+		commands[name] = cmd
+		flags[name] = config.Flags(cmd.LocalFlags(), cmd.InheritedFlags())
+	}
+
+	return func(ctx context.Context, req idaemon.Request, w io.Writer) error {
+		cmd, ok := commands[req.Command]
+		if !ok {
+			return fmt.Errorf("unknown command %q", req.Command) //nolint
+		}
+
+		store := config.New()
+		set := config.NewFlagSet(cmd.Name(), flags[req.Command], store)
+		set.SetOutput(io.Discard)
+		if err := set.Parse(req.Args); err != nil {
+			return err
+		}
+
+		args := set.Args()
+		if err := cmd.ValidateArgs(args); err != nil {
+			return err
+		}
+
+		ctx = config.WithStore(ctx, store)
+		if err := config.Load(ctx); err != nil {
+			return err
+		}
+
+		switch req.Command {
+		case "image", "video":
+			sources, err := media.ParseSources(req.Dir, args)
+			if err != nil {
+				return err
+			}
+			for _, src := range sources {
+				if src.Path == media.Stdin {
+					return errors.New("the daemon can't read standard input of clients")
+				}
+			}
+			if req.Command == "image" {
+				return image.Generate(ctx, w, sources...)
+			}
+			return video.Generate(ctx, w, sources...)
+		case "color":
+			return color.Generate(ctx, w, args[0])
+		case "regen":
+			return regen.Regenerate(ctx, w)
+		}
+
+		return fmt.Errorf("unknown command %q", req.Command) //nolint
+	}
+}
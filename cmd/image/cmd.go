@@ -1,125 +1,163 @@
 package image
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"image"
+	"io"
 	"log/slog"
 	"os"
 
 	"github.com/Nadim147c/rong/v5/internal/base16"
 	"github.com/Nadim147c/rong/v5/internal/cache"
 	"github.com/Nadim147c/rong/v5/internal/config"
+	"github.com/Nadim147c/rong/v5/internal/daemon"
 	"github.com/Nadim147c/rong/v5/internal/material"
+	"github.com/Nadim147c/rong/v5/internal/media"
 	"github.com/Nadim147c/rong/v5/internal/models"
 	"github.com/Nadim147c/rong/v5/internal/pathutil"
+	"github.com/Nadim147c/rong/v5/internal/pick"
 	"github.com/Nadim147c/rong/v5/internal/templates"
 	"github.com/spf13/cobra"
-
-	_ "image/jpeg" // for jpeg encoding
-	_ "image/png"  // for png encoding
-
-	_ "golang.org/x/image/webp" // for webp encoding
 )
 
 // Command is the image command.
 var Command = &cobra.Command{
-	Use:   "image <image>",
+	Use:   "image <image[:weight]>...",
 	Short: "Generate colors from a image",
-	Args:  cobra.ExactArgs(1),
+	Example: `
+# Generate from a image
+rong image path/to/image.png
+
+# Generate one theme from two images, counting the first twice
+rong image left.png:2 right.jpg:1
+
+# Generate from a image written to stdin
+grim - | rong image -
+  `,
+	Args: cobra.MinimumNArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		ctx := cmd.Context()
-		imagePath := args[0]
+		ctx := pick.Interactive(cmd.Context())
 
-		cwd, err := os.Getwd()
-		if err != nil {
+		if ok, err := daemon.Forward(ctx, cmd); ok {
 			return err
 		}
 
-		imagePath, err = pathutil.FindPath(cwd, imagePath)
+		cwd, err := os.Getwd()
 		if err != nil {
-			return fmt.Errorf("failed to find image path: %w", err)
+			return err
 		}
 
-		slog.Info("Generating color", "from", imagePath)
-
-		hash, err := cache.Hash(imagePath)
+		sources, err := media.ParseSources(cwd, args)
 		if err != nil {
-			return fmt.Errorf("failed to get xxh sum: %w", err)
+			return err
 		}
 
-		quantized, err := cache.LoadCache(hash)
+		dir := ""
+		if config.SaveSource.Value(ctx) && !config.DryRun.Value(ctx) {
+			dir = pathutil.StateDir
+		}
+		cleanup, err := media.ReadStdin(cmd.InOrStdin(), sources, dir)
 		if err != nil {
-			if !os.IsNotExist(err) {
-				slog.Error("Failed to load cache", "error", err)
-			}
-
-			file, err := os.Open(imagePath)
-			if err != nil {
-				return fmt.Errorf("failed to open image file: %w", err)
-			}
-			defer file.Close()
-
-			img, _, err := image.Decode(file)
-			if err != nil {
-				return fmt.Errorf("failed to decode image: %w", err)
-			}
-
-			pixels := material.GetPixelsFromImage(img)
-			quantized, err = material.Quantize(ctx, pixels)
-			if err != nil {
-				return err
-			}
-
-			if err := cache.SaveCache(hash, quantized); err != nil {
-				slog.Warn("Failed to save colors to cache", "error", err)
-			}
+			return err
 		}
+		defer cleanup()
 
-		cfg := material.GetConfig()
+		return Generate(ctx, cmd.OutOrStdout(), sources...)
+	},
+}
 
-		colorMap, err := material.GenerateFromQuantized(quantized, cfg, config.SourceColor.Value())
-		if err != nil {
-			return fmt.Errorf("failed to generate colors: %w", err)
-		}
+// quantize returns the quantized colors of the image of src and its content
+// hash. Colors are loaded from the cache when possible.
+func quantize(ctx context.Context, src media.Source, opts media.Options) (material.Quantized, string, error) {
+	m, err := media.Detect(ctx, src.Path)
+	if err != nil {
+		return material.Quantized{}, "", err
+	}
+	if m.IsVideo() {
+		return material.Quantized{}, "", fmt.Errorf("%w: %s is a video, use the video command", media.ErrUnsupported, m.Mime)
+	}
+	return cache.Quantize(ctx, src, m, opts)
+}
 
-		customs, err := material.GenerateCustomColors(colorMap["primary"])
+// Generate generates colors from images and executes templates. Colors of
+// several sources are merged by their weight. Output requested by flags (json,
+// inline template) is written to w.
+func Generate(ctx context.Context, w io.Writer, sources ...media.Source) error {
+	if len(sources) == 0 {
+		return errors.New("no image to generate colors from")
+	}
+
+	opts, err := media.GetOptions(ctx)
+	if err != nil {
+		return err
+	}
+
+	all := make([]material.Quantized, len(sources))
+	weights := make([]float64, len(sources))
+	states := make([]cache.Source, len(sources))
+	for i, src := range sources {
+		slog.Info("Generating color", "from", src.Path, "weight", src.Weight)
+
+		q, hash, err := quantize(ctx, src, opts)
 		if err != nil {
 			return err
 		}
-
-		based := base16.Generate(colorMap, quantized)
-		output := models.NewOutput(imagePath, based, colorMap, customs)
-
-		if config.JSON.Value() {
-			err := json.NewEncoder(cmd.OutOrStdout()).Encode(output)
-			if err != nil {
-				slog.Error("Failed to encode output", "error", err)
-			}
+		all[i], weights[i] = q, src.Weight
+		states[i] = cache.Source{Path: src.Name(), Hash: hash, Weight: src.Weight}
+	}
+	quantized := material.Merge(all, weights)
+	imagePath := sources[0].Name()
+
+	cfg := material.GetConfig(ctx)
+
+	source, err := pick.Source(ctx, quantized, cfg)
+	if err != nil {
+		return err
+	}
+
+	colorMap, err := material.GenerateFromQuantized(quantized, cfg, source)
+	if err != nil {
+		return fmt.Errorf("failed to generate colors: %w", err)
+	}
+
+	customs, err := material.GenerateCustomColors(ctx, colorMap["primary"])
+	if err != nil {
+		return err
+	}
+
+	based := base16.Generate(ctx, colorMap, quantized)
+	output := models.NewOutput(ctx, imagePath, based, colorMap, customs)
+
+	if config.JSON.Value(ctx) {
+		err := json.NewEncoder(w).Encode(output)
+		if err != nil {
+			slog.Error("Failed to encode output", "error", err)
 		}
+	}
 
-		if config.SimpleJSON.Value() {
-			err := models.WriteSimpleJSON(cmd.OutOrStdout(), output)
-			if err != nil {
-				slog.Error("Failed to encode output", "error", err)
-			}
+	if config.SimpleJSON.Value(ctx) {
+		err := models.WriteSimpleJSON(w, output)
+		if err != nil {
+			slog.Error("Failed to encode output", "error", err)
 		}
+	}
 
-		if tmpl := config.Template.Value(); tmpl != "" {
-			err := templates.ExecuteInline(tmpl, output, cmd.OutOrStdout())
-			if err != nil {
-				slog.Error("Failed to execute inline template", "error", err)
-			}
+	if tmpl := config.Template.Value(ctx); tmpl != "" {
+		err := templates.ExecuteInline(tmpl, output, w)
+		if err != nil {
+			slog.Error("Failed to execute inline template", "error", err)
 		}
+	}
 
-		if config.DryRun.Value() {
-			return nil
-		}
+	if config.DryRun.Value(ctx) {
+		return nil
+	}
 
-		if err := cache.SaveState(imagePath, hash, quantized); err != nil {
-			slog.Warn("Failed to save colors to cache", "error", err)
-		}
+	if err := cache.SaveState(states, quantized); err != nil {
+		slog.Warn("Failed to save colors to cache", "error", err)
+	}
 
-		return templates.Execute(ctx, output)
-	},
+	return templates.Execute(ctx, output)
 }
@@ -0,0 +1,140 @@
+package preview
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+
+	"github.com/Nadim147c/rong/v5/internal/base16"
+	"github.com/Nadim147c/rong/v5/internal/cache"
+	"github.com/Nadim147c/rong/v5/internal/config"
+	"github.com/Nadim147c/rong/v5/internal/material"
+	"github.com/Nadim147c/rong/v5/internal/media"
+	"github.com/Nadim147c/rong/v5/internal/models"
+	"github.com/Nadim147c/rong/v5/internal/pathutil"
+	"github.com/Nadim147c/rong/v5/internal/pick"
+	"github.com/charmbracelet/x/term"
+	"github.com/spf13/cobra"
+)
+
+// Command is the preview command.
+var Command = &cobra.Command{
+	Use:   "preview [media]",
+	Short: "Preview generated colors in the terminal",
+	Example: `
+# Preview colors of the current state
+rong preview
+
+# Preview colors of a image without applying them
+rong preview path/to/image.png
+
+# Preview the light theme of a video
+rong preview --dark=false path/to/video.mkv
+
+# Only show the main colors
+rong preview --compact
+  `,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := pick.Interactive(cmd.Context())
+
+		path, quantized, cleanup, err := load(ctx, cmd, args)
+		if err != nil {
+			return err
+		}
+		defer cleanup()
+
+		cfg := material.GetConfig(ctx)
+
+		source, err := pick.Source(ctx, quantized, cfg)
+		if err != nil {
+			return err
+		}
+
+		colorMap, err := material.GenerateFromQuantized(quantized, cfg, source)
+		if err != nil {
+			return fmt.Errorf("failed to generate colors: %w", err)
+		}
+
+		customs, err := material.GenerateCustomColors(ctx, colorMap["primary"])
+		if err != nil {
+			return err
+		}
+
+		based := base16.Generate(ctx, colorMap, quantized)
+		output := models.NewOutput(ctx, path, based, colorMap, customs)
+
+		w := cmd.OutOrStdout()
+		width := 80
+		if f, ok := w.(*os.File); ok && term.IsTerminal(f.Fd()) {
+			if cols, _, err := term.GetSize(f.Fd()); err == nil && cols > 0 {
+				width = cols
+			}
+		}
+
+		p := printer{w: w, width: width, colors: output.Colors, customs: customs}
+		if config.Compact.Value(ctx) {
+			return p.compact(output)
+		}
+		return p.full(output)
+	},
+}
+
+// load returns the quantized colors of the media of args, or of the current
+// state without args. cleanup removes the temporary copy of media read from
+// stdin.
+func load(
+	ctx context.Context,
+	cmd *cobra.Command,
+	args []string,
+) (string, material.Quantized, func(), error) {
+	nop := func() {}
+	if len(args) == 0 {
+		state, err := cache.LoadState()
+		if err != nil {
+			return "", material.Quantized{}, nop, fmt.Errorf("failed load current state: %w", err)
+		}
+		return state.Path, state.Quantized, nop, nil
+	}
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		return "", material.Quantized{}, nop, err
+	}
+
+	src := media.Source{Path: media.Stdin, Weight: 1}
+	if args[0] != media.Stdin {
+		src.Path, err = pathutil.FindPath(cwd, args[0])
+		if err != nil {
+			return "", material.Quantized{}, nop, fmt.Errorf("failed to find media path: %w", err)
+		}
+	}
+
+	sources := []media.Source{src}
+	cleanup, err := media.ReadStdin(cmd.InOrStdin(), sources, "")
+	if err != nil {
+		return "", material.Quantized{}, nop, err
+	}
+	src = sources[0]
+
+	slog.Info("Generating color", "from", src.Path)
+	m, err := media.Detect(ctx, src.Path)
+	if err != nil {
+		cleanup()
+		return "", material.Quantized{}, nop, err
+	}
+	opts, err := media.GetOptions(ctx)
+	if err != nil {
+		cleanup()
+		return "", material.Quantized{}, nop, err
+	}
+
+	// Previews only read the cache, so looking at media doesn't change it.
+	quantized, err := cache.Peek(ctx, src, m, opts)
+	if err != nil {
+		cleanup()
+		return "", material.Quantized{}, nop, err
+	}
+	return src.Name(), quantized, cleanup, nil
+}
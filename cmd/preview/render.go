@@ -0,0 +1,272 @@
+package preview
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/Nadim147c/material/v3/color"
+	"github.com/Nadim147c/rong/v5/internal/material"
+	"github.com/Nadim147c/rong/v5/internal/models"
+)
+
+// sample is the text shown over every color with text on it.
+const sample = "The quick brown fox jumps over the lazy dog"
+
+// mainRoles are the roles shown by the compact preview.
+var mainRoles = []string{
+	"primary", "secondary", "tertiary", "error",
+	"surface", "surface_container", "outline",
+}
+
+// printer renders generated colors to a terminal with truecolor escapes.
+type printer struct {
+	w       io.Writer
+	width   int
+	colors  []models.NamedColor
+	customs map[string]material.CustomColor
+}
+
+// paint renders text on bg in fg.
+func paint(text string, bg, fg models.FormatedColor) string {
+	return "\x1b[" + bg.AnsiBackground + ";" + fg.AnsiForeground + "m" + text + "\x1b[0m"
+}
+
+// contrast returns black or white, whichever is more readable on c.
+func contrast(c models.FormatedColor) models.FormatedColor {
+	luma := 0.299*float64(c.Red) + 0.587*float64(c.Green) + 0.114*float64(c.Blue)
+	if luma > 140 {
+		return models.NewFormatedColor(color.ARGB(0xFF000000))
+	}
+	return models.NewFormatedColor(color.ARGB(0xFFFFFFFF))
+}
+
+// lookup returns the color named name.
+func (p printer) lookup(name string) (models.FormatedColor, bool) {
+	i := slices.IndexFunc(p.colors, func(c models.NamedColor) bool {
+		return c.Name.Snake == name
+	})
+	if i < 0 {
+		return models.FormatedColor{}, false
+	}
+	return p.colors[i].Color, true
+}
+
+// on returns the name of the color of text on the color named name. Surface
+// containers use the text color of the surface and fixed dim colors use the
+// text color of their fixed color.
+func (p printer) on(name string) (string, bool) {
+	candidates := []string{"on_" + name, "on_" + strings.TrimSuffix(name, "_dim")}
+	if name == "inverse_surface" {
+		candidates = []string{"inverse_on_surface"}
+	} else if strings.HasPrefix(name, "surface_") && name != "surface_tint" {
+		candidates = append(candidates, "on_surface")
+	}
+	for _, c := range candidates {
+		if _, ok := p.lookup(c); ok {
+			return c, true
+		}
+	}
+	return "", false
+}
+
+// text returns the color of the label of the color named name: its text color,
+// the color a text color is used on, or black or white.
+func (p printer) text(name string) models.FormatedColor {
+	if on, ok := p.on(name); ok {
+		c, _ := p.lookup(on)
+		return c
+	}
+	if base, ok := strings.CutPrefix(name, "on_"); ok {
+		if c, ok := p.lookup(base); ok {
+			return c
+		}
+	}
+	c, _ := p.lookup(name)
+	return contrast(c)
+}
+
+// groups returns the names of material and custom colors. Every text color
+// follows the color it is used on.
+func (p printer) groups() (roles, customs []string) {
+	custom := map[string]bool{}
+	for key := range p.customs {
+		key = models.NewColorName(key).Snake
+		custom[key] = true
+		custom["on_"+key] = true
+		custom[key+"_container"] = true
+		custom["on_"+key+"_container"] = true
+	}
+
+	for _, c := range p.colors {
+		name := c.Name.Snake
+		switch {
+		case custom[name]:
+			customs = append(customs, name)
+		case isBase16(name):
+		default:
+			roles = append(roles, name)
+		}
+	}
+
+	order := func(a, b string) int {
+		baseA, onA := strings.CutPrefix(a, "on_")
+		baseB, onB := strings.CutPrefix(b, "on_")
+		if c := strings.Compare(baseA, baseB); c != 0 {
+			return c
+		}
+		if onA == onB {
+			return 0
+		}
+		if onA {
+			return 1
+		}
+		return -1
+	}
+	slices.SortFunc(roles, order)
+	slices.SortFunc(customs, order)
+	return roles, customs
+}
+
+// isBase16 reports whether name is a base16 color (color_0 to color_f).
+func isBase16(name string) bool {
+	return len(name) == len("color_0") && strings.HasPrefix(name, "color_")
+}
+
+// ansiRows returns the normal and bright base16 colors.
+func ansiRows(b models.Base16) (normal, bright []models.FormatedColor) {
+	normal = []models.FormatedColor{
+		b.Color0, b.Color1, b.Color2, b.Color3,
+		b.Color4, b.Color5, b.Color6, b.Color7,
+	}
+	bright = []models.FormatedColor{
+		b.Color8, b.Color9, b.ColorA, b.ColorB,
+		b.ColorC, b.ColorD, b.ColorE, b.ColorF,
+	}
+	return normal, bright
+}
+
+// header writes the source and the theme of the colors.
+func (p printer) header(b *strings.Builder, output models.Output) {
+	theme := "light"
+	if output.Dark {
+		theme = "dark"
+	}
+	if output.Image == "" {
+		fmt.Fprintf(b, "Colors (%s)\n", theme)
+		return
+	}
+	fmt.Fprintf(b, "Colors of %s (%s)\n", output.Image, theme)
+}
+
+// grid writes the colors of names labelled with their name and hex in as many
+// columns as fit the width.
+func (p printer) grid(b *strings.Builder, names []string) {
+	nameWidth := 0
+	for _, name := range names {
+		nameWidth = max(nameWidth, len(name))
+	}
+	cellWidth := nameWidth + len("   #RRGGBB ")
+	columns := max(p.width/cellWidth, 1)
+
+	for i, name := range names {
+		c, _ := p.lookup(name)
+		label := fmt.Sprintf(" %-*s  %s ", nameWidth, name, c.HexRGB)
+		b.WriteString(paint(label, c, p.text(name)))
+		if (i+1)%columns == 0 || i == len(names)-1 {
+			b.WriteByte('\n')
+		}
+	}
+}
+
+// samples writes sample text in every text color over the color it is used on.
+func (p printer) samples(b *strings.Builder, names []string) {
+	for _, name := range names {
+		on, ok := p.on(name)
+		if !ok {
+			continue
+		}
+		bg, _ := p.lookup(name)
+		fg, _ := p.lookup(on)
+		line := fmt.Sprintf(" %s on %s: %s", on, name, sample)
+		if len(line) < p.width {
+			line += strings.Repeat(" ", p.width-len(line))
+		}
+		b.WriteString(paint(line[:min(len(line), p.width)], bg, fg))
+		b.WriteByte('\n')
+	}
+}
+
+// full writes every material role, the base16 colors, the custom colors and
+// sample text on every color with a text color.
+func (p printer) full(output models.Output) error {
+	var b strings.Builder
+	roles, customs := p.groups()
+
+	p.header(&b, output)
+
+	b.WriteString("\nMaterial\n")
+	p.grid(&b, roles)
+
+	b.WriteString("\nBase16\n")
+	normal, bright := ansiRows(output.Base16)
+	for i, row := range [][]models.FormatedColor{normal, bright} {
+		for j, c := range row {
+			label := fmt.Sprintf(" %X %s ", i*len(row)+j, c.TrimmedHexRGB)
+			b.WriteString(paint(label, c, contrast(c)))
+		}
+		b.WriteByte('\n')
+	}
+
+	if len(customs) > 0 {
+		b.WriteString("\nCustom\n")
+		p.grid(&b, customs)
+	}
+
+	b.WriteString("\nSamples\n")
+	p.samples(&b, roles)
+	p.samples(&b, customs)
+
+	_, err := io.WriteString(p.w, b.String())
+	return err
+}
+
+// compact writes the main roles, the base16 colors as blocks and the custom
+// colors on a few lines.
+func (p printer) compact(output models.Output) error {
+	var b strings.Builder
+	p.header(&b, output)
+
+	for _, name := range mainRoles {
+		if c, ok := p.lookup(name); ok {
+			b.WriteString(paint(" "+name+" ", c, p.text(name)))
+		}
+	}
+	b.WriteByte('\n')
+
+	normal, bright := ansiRows(output.Base16)
+	for _, row := range [][]models.FormatedColor{normal, bright} {
+		for _, c := range row {
+			b.WriteString(paint("    ", c, c))
+		}
+		b.WriteByte('\n')
+	}
+
+	keys := make([]string, 0, len(p.customs))
+	for key := range p.customs {
+		keys = append(keys, models.NewColorName(key).Snake)
+	}
+	slices.Sort(keys)
+	for _, key := range keys {
+		if c, ok := p.lookup(key); ok {
+			b.WriteString(paint(" "+key+" ", c, p.text(key)))
+		}
+	}
+	if len(keys) > 0 {
+		b.WriteByte('\n')
+	}
+
+	_, err := io.WriteString(p.w, b.String())
+	return err
+}
@@ -1,18 +1,21 @@
 package regen
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"log/slog"
-	"strings"
 
 	"github.com/Nadim147c/rong/v5/internal/base16"
 	"github.com/Nadim147c/rong/v5/internal/cache"
 	"github.com/Nadim147c/rong/v5/internal/config"
+	"github.com/Nadim147c/rong/v5/internal/daemon"
 	"github.com/Nadim147c/rong/v5/internal/material"
+	"github.com/Nadim147c/rong/v5/internal/media"
 	"github.com/Nadim147c/rong/v5/internal/models"
+	"github.com/Nadim147c/rong/v5/internal/pick"
 	"github.com/Nadim147c/rong/v5/internal/templates"
-	"github.com/gabriel-vasile/mimetype"
 	"github.com/spf13/cobra"
 )
 
@@ -22,63 +25,78 @@ var Command = &cobra.Command{
 	Short: "Regenerate colors from previous generation",
 	Args:  cobra.NoArgs,
 	RunE: func(cmd *cobra.Command, _ []string) error {
-		ctx := cmd.Context()
-		state, err := cache.LoadState()
-		if err != nil {
-			return fmt.Errorf("failed load current state: %v", err) //nolint
+		ctx := pick.Interactive(cmd.Context())
+
+		if ok, err := daemon.Forward(ctx, cmd); ok {
+			return err
 		}
 
-		slog.Info("Generating color from cached state", "path", state.Path)
+		return Regenerate(ctx, cmd.OutOrStdout())
+	},
+}
 
-		cfg := material.GetConfig()
+// Regenerate generates colors from the saved state and executes templates.
+// Output requested by flags (json, inline template) is written to w.
+func Regenerate(ctx context.Context, w io.Writer) error {
+	state, err := cache.LoadState()
+	if err != nil {
+		return fmt.Errorf("failed load current state: %v", err) //nolint
+	}
 
-		colorMap, err := material.GenerateFromQuantized(state.Quantized, cfg, config.SourceColor.Value())
-		if err != nil {
-			return fmt.Errorf("failed to generate colors: %w", err)
-		}
+	slog.Info("Generating color from cached state", "path", state.Path)
 
-		customs, err := material.GenerateCustomColors(colorMap["primary"])
-		if err != nil {
-			return err
-		}
+	cfg := material.GetConfig(ctx)
 
-		based := base16.Generate(colorMap, state.Quantized)
+	source, err := pick.Source(ctx, state.Quantized, cfg)
+	if err != nil {
+		return err
+	}
 
-		path := state.Path
-		mtype, err := mimetype.DetectFile(state.Path)
-		if err == nil && strings.HasPrefix(mtype.String(), "video") {
-			if preview, err := cache.GetPreview(path, state.Hash); err == nil {
-				path = preview
-			}
-		}
+	colorMap, err := material.GenerateFromQuantized(state.Quantized, cfg, source)
+	if err != nil {
+		return fmt.Errorf("failed to generate colors: %w", err)
+	}
+
+	customs, err := material.GenerateCustomColors(ctx, colorMap["primary"])
+	if err != nil {
+		return err
+	}
 
-		output := models.NewOutput(path, based, colorMap, customs)
+	based := base16.Generate(ctx, colorMap, state.Quantized)
 
-		if config.JSON.Value() {
-			err := json.NewEncoder(cmd.OutOrStdout()).Encode(output)
-			if err != nil {
-				slog.Error("Failed to encode output", "error", err)
-			}
+	path := state.Path
+	if m, err := media.Detect(ctx, state.Path); err == nil && m.IsVideo() {
+		if preview, err := cache.GetPreview(ctx, path, state.Hash); err == nil {
+			path = preview
 		}
+	}
 
-		if config.SimpleJSON.Value() {
-			err := models.WriteSimpleJSON(cmd.OutOrStdout(), output)
-			if err != nil {
-				slog.Error("Failed to encode output", "error", err)
-			}
+	output := models.NewOutput(ctx, path, based, colorMap, customs)
+
+	if config.JSON.Value(ctx) {
+		err := json.NewEncoder(w).Encode(output)
+		if err != nil {
+			slog.Error("Failed to encode output", "error", err)
 		}
+	}
 
-		if tmpl := config.Template.Value(); tmpl != "" {
-			err := templates.ExecuteInline(tmpl, output, cmd.OutOrStdout())
-			if err != nil {
-				slog.Error("Failed to execute inline template", "error", err)
-			}
+	if config.SimpleJSON.Value(ctx) {
+		err := models.WriteSimpleJSON(w, output)
+		if err != nil {
+			slog.Error("Failed to encode output", "error", err)
 		}
+	}
 
-		if config.DryRun.Value() {
-			return nil
+	if tmpl := config.Template.Value(ctx); tmpl != "" {
+		err := templates.ExecuteInline(tmpl, output, w)
+		if err != nil {
+			slog.Error("Failed to execute inline template", "error", err)
 		}
+	}
 
-		return templates.Execute(ctx, output)
-	},
+	if config.DryRun.Value(ctx) {
+		return nil
+	}
+
+	return templates.Execute(ctx, output)
 }
@@ -14,13 +14,16 @@ import (
 	"github.com/Nadim147c/fang"
 	"github.com/Nadim147c/rong/v5/cmd/cache"
 	"github.com/Nadim147c/rong/v5/cmd/color"
+	"github.com/Nadim147c/rong/v5/cmd/daemon"
 	"github.com/Nadim147c/rong/v5/cmd/image"
+	"github.com/Nadim147c/rong/v5/cmd/preview"
 	"github.com/Nadim147c/rong/v5/cmd/regen"
+	"github.com/Nadim147c/rong/v5/cmd/rotate"
 	"github.com/Nadim147c/rong/v5/cmd/score"
 	"github.com/Nadim147c/rong/v5/cmd/video"
+	"github.com/Nadim147c/rong/v5/cmd/watch"
 	"github.com/Nadim147c/rong/v5/internal/config"
 	ilog "github.com/Nadim147c/rong/v5/internal/log"
-	"github.com/Nadim147c/rong/v5/internal/pathutil"
 	"github.com/carapace-sh/carapace"
 	"github.com/carapace-sh/carapace/pkg/style"
 	"github.com/charmbracelet/log"
@@ -37,38 +40,50 @@ func init() {
 	Command.AddCommand(cache.Command)
 	Command.AddCommand(regen.Command)
 	Command.AddCommand(score.Command)
+	Command.AddCommand(preview.Command)
+	Command.AddCommand(watch.Command)
+	Command.AddCommand(daemon.Command)
+	Command.AddCommand(rotate.Random)
+	Command.AddCommand(rotate.Next)
+	Command.AddCommand(rotate.Prev)
+
+	schemeFlags := pflag.NewFlagSet("scheme", pflag.ContinueOnError)
+	config.Dark.RegisterFlag(schemeFlags)
+	config.MaterialContrast.RegisterFlag(schemeFlags)
+	config.MaterialCustomBlend.RegisterFlag(schemeFlags)
+	config.MaterialCustomColors.RegisterFlag(schemeFlags)
+	config.MaterialPlatformt.RegisterFlag(schemeFlags)
+	config.MaterialVariant.RegisterFlag(schemeFlags)
+	config.MaterialVersion.RegisterFlag(schemeFlags)
+
+	config.Base16Blend.RegisterFlag(schemeFlags)
+	config.Base16Method.RegisterFlag(schemeFlags)
+
+	config.Base16Black.RegisterFlag(schemeFlags)
+	config.Base16Blue.RegisterFlag(schemeFlags)
+	config.Base16Cyan.RegisterFlag(schemeFlags)
+	config.Base16Green.RegisterFlag(schemeFlags)
+	config.Base16Magenta.RegisterFlag(schemeFlags)
+	config.Base16Red.RegisterFlag(schemeFlags)
+	config.Base16White.RegisterFlag(schemeFlags)
+	config.Base16Yellow.RegisterFlag(schemeFlags)
 
 	commonFlags := pflag.NewFlagSet("generate", pflag.ContinueOnError)
-	config.Dark.RegisterFlag(commonFlags)
+	commonFlags.AddFlagSet(schemeFlags)
 	config.JSON.RegisterFlag(commonFlags)
 	config.SimpleJSON.RegisterFlag(commonFlags)
 	config.DryRun.RegisterFlag(commonFlags)
 	config.Template.RegisterFlag(commonFlags)
 
-	config.MaterialContrast.RegisterFlag(commonFlags)
-	config.MaterialCustomBlend.RegisterFlag(commonFlags)
-	config.MaterialCustomColors.RegisterFlag(commonFlags)
-	config.MaterialPlatformt.RegisterFlag(commonFlags)
-	config.MaterialVariant.RegisterFlag(commonFlags)
-	config.MaterialVersion.RegisterFlag(commonFlags)
-
-	config.Base16Blend.RegisterFlag(commonFlags)
-	config.Base16Method.RegisterFlag(commonFlags)
-
-	config.Base16Black.RegisterFlag(commonFlags)
-	config.Base16Blue.RegisterFlag(commonFlags)
-	config.Base16Cyan.RegisterFlag(commonFlags)
-	config.Base16Green.RegisterFlag(commonFlags)
-	config.Base16Magenta.RegisterFlag(commonFlags)
-	config.Base16Red.RegisterFlag(commonFlags)
-	config.Base16White.RegisterFlag(commonFlags)
-	config.Base16Yellow.RegisterFlag(commonFlags)
-
 	generateCmds := []*cobra.Command{
 		color.Command,
 		image.Command,
 		regen.Command,
+		rotate.Next,
+		rotate.Prev,
+		rotate.Random,
 		video.Command,
+		watch.Command,
 	}
 	for cmd := range slices.Values(generateCmds) {
 		cmd.Flags().AddFlagSet(commonFlags)
@@ -78,16 +93,77 @@ func init() {
 	config.SourceColor.RegisterFlag(image.Command.Flags())
 	config.SourceColor.RegisterFlag(video.Command.Flags())
 	config.SourceColor.RegisterFlag(regen.Command.Flags())
+	config.SourceColor.RegisterFlag(watch.Command.Flags())
+
+	config.SourceRank.RegisterFlag(image.Command.Flags())
+	config.SourceRank.RegisterFlag(video.Command.Flags())
+	config.SourceRank.RegisterFlag(regen.Command.Flags())
+	config.SourceRank.RegisterFlag(watch.Command.Flags())
+
+	config.Pick.RegisterFlag(image.Command.Flags())
+	config.Pick.RegisterFlag(video.Command.Flags())
+	config.Pick.RegisterFlag(regen.Command.Flags())
+
+	config.SaveSource.RegisterFlag(image.Command.Flags())
+	config.SaveSource.RegisterFlag(video.Command.Flags())
+
+	quantizeFlagSet := pflag.NewFlagSet("quantize", pflag.ContinueOnError)
+	config.QuantizeMaxPixels.RegisterFlag(quantizeFlagSet)
+	config.QuantizeMethod.RegisterFlag(quantizeFlagSet)
+	config.QuantizeMaxColors.RegisterFlag(quantizeFlagSet)
+	config.QuantizeIterations.RegisterFlag(quantizeFlagSet)
+	config.QuantizeMinTone.RegisterFlag(quantizeFlagSet)
+	config.QuantizeMaxTone.RegisterFlag(quantizeFlagSet)
+	config.QuantizeMinChroma.RegisterFlag(quantizeFlagSet)
+	config.Crop.RegisterFlag(quantizeFlagSet)
+	config.TrimBorders.RegisterFlag(quantizeFlagSet)
+	config.CenterWeight.RegisterFlag(quantizeFlagSet)
+	config.FrameSize.RegisterFlag(quantizeFlagSet)
+	config.Decoders.RegisterFlag(quantizeFlagSet)
+	config.DecodeTimeout.RegisterFlag(quantizeFlagSet)
+	image.Command.Flags().AddFlagSet(quantizeFlagSet)
+
+	previewFlagSet := pflag.NewFlagSet("preview", pflag.ContinueOnError)
+	config.PreviewFormat.RegisterFlag(previewFlagSet)
+	config.PreviewMode.RegisterFlag(previewFlagSet)
+	config.PreviewSeek.RegisterFlag(previewFlagSet)
+	config.PreviewSize.RegisterFlag(previewFlagSet)
+	config.PreviewQuality.RegisterFlag(previewFlagSet)
+	config.PreviewDuration.RegisterFlag(previewFlagSet)
+	config.PreviewGrid.RegisterFlag(previewFlagSet)
+	regen.Command.Flags().AddFlagSet(previewFlagSet)
 
 	videoFlagSet := pflag.NewFlagSet("video", pflag.ContinueOnError)
-	config.PreviewFormat.RegisterFlag(videoFlagSet)
+	videoFlagSet.AddFlagSet(previewFlagSet)
 	config.FFmpegDuration.RegisterFlag(videoFlagSet)
 	config.FFmpegFrames.RegisterFlag(videoFlagSet)
+	config.Sampling.RegisterFlag(videoFlagSet)
+	config.FFmpegOffset.RegisterFlag(videoFlagSet)
+	config.SceneThreshold.RegisterFlag(videoFlagSet)
+	videoFlagSet.AddFlagSet(quantizeFlagSet)
+
+	scanFlagSet := pflag.NewFlagSet("scan", pflag.ContinueOnError)
+	config.Exclude.RegisterFlag(scanFlagSet)
+	config.FollowSymlinks.RegisterFlag(scanFlagSet)
+	config.MaxDepth.RegisterFlag(scanFlagSet)
+	config.Hidden.RegisterFlag(scanFlagSet)
+
+	rotateFlagSet := pflag.NewFlagSet("rotate", pflag.ContinueOnError)
+	config.RotateHistory.RegisterFlag(rotateFlagSet)
+	config.RotatePreferCached.RegisterFlag(rotateFlagSet)
+	config.SourceColor.RegisterFlag(rotateFlagSet)
+	config.SourceRank.RegisterFlag(rotateFlagSet)
+	rotateFlagSet.AddFlagSet(videoFlagSet)
+	rotateFlagSet.AddFlagSet(scanFlagSet)
 
 	scoreFlagSet := pflag.NewFlagSet("score", pflag.ContinueOnError)
 	config.FFmpegDuration.RegisterFlag(scoreFlagSet)
 	config.FFmpegFrames.RegisterFlag(scoreFlagSet)
+	config.Sampling.RegisterFlag(scoreFlagSet)
+	config.FFmpegOffset.RegisterFlag(scoreFlagSet)
+	config.SceneThreshold.RegisterFlag(scoreFlagSet)
 	config.MergeThreshold.RegisterFlag(scoreFlagSet)
+	scoreFlagSet.AddFlagSet(quantizeFlagSet)
 
 	carapace.Gen(image.Command).PositionalAnyCompletion(carapace.ActionFiles())
 
@@ -95,11 +171,34 @@ func init() {
 	carapace.Gen(video.Command).PositionalAnyCompletion(carapace.ActionFiles())
 
 	cache.Command.Flags().AddFlagSet(videoFlagSet)
+	cache.Command.Flags().AddFlagSet(scanFlagSet)
+	cache.Import.Flags().AddFlagSet(scanFlagSet)
 	carapace.Gen(cache.Command).PositionalAnyCompletion(carapace.ActionFiles())
 
+	for cmd := range slices.Values([]*cobra.Command{rotate.Random, rotate.Next, rotate.Prev}) {
+		cmd.Flags().AddFlagSet(rotateFlagSet)
+		carapace.Gen(cmd).PositionalAnyCompletion(carapace.ActionDirectories())
+	}
+
 	score.Command.Flags().AddFlagSet(scoreFlagSet)
 	carapace.Gen(score.Command).PositionalAnyCompletion(carapace.ActionFiles())
 
+	previewFlags := preview.Command.Flags()
+	previewFlags.AddFlagSet(schemeFlags)
+	config.SourceColor.RegisterFlag(previewFlags)
+	config.SourceRank.RegisterFlag(previewFlags)
+	config.Pick.RegisterFlag(previewFlags)
+	config.Compact.RegisterFlag(previewFlags)
+	config.FFmpegDuration.RegisterFlag(previewFlags)
+	config.FFmpegFrames.RegisterFlag(previewFlags)
+	config.Sampling.RegisterFlag(previewFlags)
+	config.FFmpegOffset.RegisterFlag(previewFlags)
+	config.SceneThreshold.RegisterFlag(previewFlags)
+	previewFlags.AddFlagSet(quantizeFlagSet)
+	previewComp := carapace.Gen(preview.Command)
+	previewComp.FlagCompletion(config.CarapaceAction)
+	previewComp.PositionalCompletion(carapace.ActionFiles())
+
 	colorComp := carapace.Gen(color.Command)
 	colorComp.FlagCompletion(config.CarapaceAction)
 	nameCompletions := make([]string, 0, len(color.Names)*2)
@@ -122,6 +221,7 @@ func init() {
 	config.Quiet.RegisterFlag(persFlags)
 	config.LogFile.RegisterFlag(persFlags)
 	config.Config.RegisterFlag(persFlags)
+	config.Daemon.RegisterFlag(persFlags)
 }
 
 func handleError(w io.Writer, styles fang.Styles, err error) {
@@ -171,7 +271,7 @@ var Command = &cobra.Command{
 			return nil
 		}
 
-		level := slog.LevelWarn - slog.Level(config.Verbose.Value()*4)
+		level := slog.LevelWarn - slog.Level(config.Verbose.Value(cmd.Context())*4)
 
 		quiet := should(cmd.Flags().GetBool("quiet"))
 		if quiet {
@@ -217,21 +317,6 @@ var Command = &cobra.Command{
 			viper.SetOptions(viper.WithLogger(logger))
 		}
 
-		viper.SetEnvPrefix("rong")
-		viper.AutomaticEnv()
-
-		if value := config.Config.Value(); value != "" {
-			slog.Info("Cofniguration path has been set", "value", value)
-			if slices.Contains([]string{"no", "0", "false"}, value) {
-				return nil
-			}
-			viper.SetConfigFile(value)
-		} else {
-			viper.AddConfigPath("/etc/rong")
-			viper.AddConfigPath(pathutil.ConfigDir)
-			viper.SetConfigName("config")
-		}
-
-		return viper.ReadInConfig()
+		return config.Load(cmd.Context())
 	},
 }
@@ -0,0 +1,78 @@
+package rotate
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"slices"
+
+	"github.com/spf13/cobra"
+)
+
+// Next is the next command.
+var Next = &cobra.Command{
+	Use:   "next [dir...]",
+	Short: "Generate colors from the next image or video in directories",
+	Example: `
+# Start rotating through a directory
+rong next ~/Pictures/Wallpapers
+
+# Continue with the directories used last time
+rong next
+  `,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return step(cmd.Context(), cmd.OutOrStdout(), args, 1)
+	},
+}
+
+// Prev is the prev command.
+var Prev = &cobra.Command{
+	Use:   "prev [dir...]",
+	Short: "Generate colors from the previous image or video in directories",
+	Example: `
+# Go back to the previous wallpaper
+rong prev
+  `,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return step(cmd.Context(), cmd.OutOrStdout(), args, -1)
+	},
+}
+
+// step moves the position by offset through the media found in the
+// directories and generates colors from it.
+func step(ctx context.Context, w io.Writer, args []string, offset int) error {
+	s, err := loadState()
+	if err != nil {
+		slog.Warn("Failed to load rotation state", "error", err)
+	}
+
+	dirs, err := resolveDirs(args, s)
+	if err != nil {
+		return err
+	}
+	s.Dirs = dirs
+
+	media, err := findMedia(ctx, dirs)
+	if err != nil {
+		return err
+	}
+
+	n := len(media)
+	i := slices.Index(media, s.Current)
+	switch {
+	case i < 0 && offset > 0:
+		i = 0
+	case i < 0:
+		i = n - 1
+	default:
+		i = ((i+offset)%n + n) % n
+	}
+
+	// Media after the one at i in the direction of offset are tried when it
+	// fails.
+	paths := make([]string, 0, n)
+	for j := range n {
+		paths = append(paths, media[((i+j*offset)%n+n)%n])
+	}
+	return generate(ctx, w, s, paths)
+}
@@ -0,0 +1,62 @@
+package rotate
+
+import (
+	"log/slog"
+	"math/rand/v2"
+	"slices"
+
+	"github.com/spf13/cobra"
+)
+
+// Random is the random command.
+var Random = &cobra.Command{
+	Use:   "random [dir...]",
+	Short: "Generate colors from a random image or video in directories",
+	Example: `
+# Pick a random wallpaper
+rong random ~/Pictures/Wallpapers
+
+# Pick from the directories used last time
+rong random
+
+# Only pick wallpapers that are already cached
+rong random --rotate.prefer-cached ~/Pictures/Wallpapers ~/Videos/Wallpapers
+  `,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+
+		s, err := loadState()
+		if err != nil {
+			slog.Warn("Failed to load rotation state", "error", err)
+		}
+
+		dirs, err := resolveDirs(args, s)
+		if err != nil {
+			return err
+		}
+		s.Dirs = dirs
+
+		media, err := findMedia(ctx, dirs)
+		if err != nil {
+			return err
+		}
+
+		candidates := slices.DeleteFunc(slices.Clone(media), func(path string) bool {
+			return slices.Contains(s.History, path)
+		})
+		if len(candidates) == 0 {
+			slog.Info("All media were used recently, ignoring history")
+			candidates = media
+			if len(media) > 1 {
+				candidates = slices.DeleteFunc(media, func(path string) bool {
+					return path == s.Current
+				})
+			}
+		}
+
+		rand.Shuffle(len(candidates), func(i, j int) { //nolint:gosec
+			candidates[i], candidates[j] = candidates[j], candidates[i]
+		})
+		return generate(ctx, cmd.OutOrStdout(), s, candidates)
+	},
+}
@@ -0,0 +1,206 @@
+// Package rotate implements commands that pick a wallpaper from directories and
+// generate colors from it.
+package rotate
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/Nadim147c/rong/v5/cmd/image"
+	"github.com/Nadim147c/rong/v5/cmd/video"
+	"github.com/Nadim147c/rong/v5/internal/cache"
+	"github.com/Nadim147c/rong/v5/internal/config"
+	"github.com/Nadim147c/rong/v5/internal/media"
+	"github.com/Nadim147c/rong/v5/internal/pathutil"
+	"github.com/Nadim147c/rong/v5/internal/scan"
+	"github.com/google/renameio/v2"
+)
+
+var (
+	// ErrNoDirs means no directories were given and none were remembered.
+	ErrNoDirs = errors.New("no directories given and none used before")
+	// ErrNoMedia means the directories don't contain any image or video.
+	ErrNoMedia = errors.New("no image or video found")
+)
+
+// statePath is where the rotation position is saved.
+var statePath = filepath.Join(pathutil.StateDir, "rotate.json")
+
+// state is the rotation position saved between runs.
+type state struct {
+	// Dirs are the directories used last time.
+	Dirs []string `json:"dirs"`
+	// Current is the media used last time.
+	Current string `json:"current"`
+	// History are recently used media, oldest first.
+	History []string `json:"history"`
+}
+
+func loadState() (state, error) {
+	var s state
+
+	file, err := os.Open(statePath)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return s, err
+	}
+	defer file.Close()
+
+	err = json.NewDecoder(file).Decode(&s)
+	return s, err
+}
+
+// save replaces the saved state atomically, so an interrupted or concurrent
+// run never leaves a partial state behind.
+func (s *state) save() error {
+	if err := os.MkdirAll(filepath.Dir(statePath), 0o750); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	return renameio.WriteFile(statePath, data, 0o640)
+}
+
+// use marks path as current and adds it to the history.
+func (s *state) use(ctx context.Context, path string) {
+	s.Current = path
+	s.History = slices.DeleteFunc(s.History, func(p string) bool {
+		return p == path
+	})
+	s.History = append(s.History, path)
+
+	limit := max(config.RotateHistory.Value(ctx), 0)
+	if len(s.History) > limit {
+		s.History = s.History[len(s.History)-limit:]
+	}
+}
+
+// resolveDirs returns absolute paths of args, or the remembered directories
+// when args is empty.
+func resolveDirs(args []string, s state) ([]string, error) {
+	if len(args) == 0 {
+		if len(s.Dirs) == 0 {
+			return nil, ErrNoDirs
+		}
+		return s.Dirs, nil
+	}
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		return nil, err
+	}
+
+	dirs := make([]string, 0, len(args))
+	for arg := range slices.Values(args) {
+		dir, err := pathutil.FindPath(cwd, arg)
+		if err != nil {
+			return nil, fmt.Errorf("failed to find path %q: %w", arg, err)
+		}
+		dirs = append(dirs, dir)
+	}
+	return dirs, nil
+}
+
+// findMedia returns media in dirs in the order they were found.
+func findMedia(ctx context.Context, dirs []string) ([]string, error) {
+	paths := make(chan string, 100)
+	go func() {
+		_ = scan.Find(ctx, dirs, paths)
+		close(paths)
+	}()
+
+	var media []string
+	for path := range paths {
+		media = append(media, path)
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if len(media) == 0 {
+		return nil, ErrNoMedia
+	}
+
+	if config.RotatePreferCached.Value(ctx) {
+		cached := slices.DeleteFunc(slices.Clone(media), func(path string) bool {
+			return !isCached(ctx, path)
+		})
+		if len(cached) != 0 {
+			return cached, nil
+		}
+		slog.Info("No cached media found, using all media")
+	}
+
+	return media, nil
+}
+
+func isCached(ctx context.Context, path string) bool {
+	hash, err := cache.Hash(path)
+	if err != nil {
+		return false
+	}
+	m, err := media.Detect(ctx, path)
+	if err != nil {
+		return false
+	}
+	opts, err := media.GetOptions(ctx)
+	if err != nil {
+		return false
+	}
+	return cache.IsCached(ctx, cache.Variant(hash, opts.Key(m)), m.IsVideo())
+}
+
+// maxAttempts is the number of media tried before giving up.
+const maxAttempts = 5
+
+// generate runs the image or video pipeline for the first of paths it
+// succeeds for and saves the new rotation state. Media that fail are skipped
+// and not recorded.
+func generate(ctx context.Context, w io.Writer, s state, paths []string) error {
+	var errs []error
+	for _, path := range paths[:min(len(paths), maxAttempts)] {
+		err := generateFrom(ctx, w, path)
+		if err == nil {
+			if config.DryRun.Value(ctx) {
+				return nil
+			}
+			s.use(ctx, path)
+			if err := s.save(); err != nil {
+				slog.Warn("Failed to save rotation state", "error", err)
+			}
+			return nil
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		slog.Warn("Failed to generate colors, skipping media", "path", path, "error", err)
+		errs = append(errs, fmt.Errorf("%s: %w", path, err))
+	}
+	return errors.Join(errs...)
+}
+
+// generateFrom runs the image or video pipeline for path.
+func generateFrom(ctx context.Context, w io.Writer, path string) error {
+	m, err := media.Detect(ctx, path)
+	if err != nil {
+		return err
+	}
+
+	src := media.Source{Path: path, Weight: 1}
+	if m.IsVideo() {
+		return video.Generate(ctx, w, src)
+	}
+	return image.Generate(ctx, w, src)
+}
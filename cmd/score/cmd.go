@@ -11,8 +11,7 @@ import (
 	"github.com/Nadim147c/material/v3/score"
 	"github.com/Nadim147c/rong/v5/internal/cache"
 	"github.com/Nadim147c/rong/v5/internal/config"
-	"github.com/Nadim147c/rong/v5/internal/ffmpeg"
-	"github.com/Nadim147c/rong/v5/internal/material"
+	"github.com/Nadim147c/rong/v5/internal/media"
 	"github.com/Nadim147c/rong/v5/internal/pathutil"
 	"github.com/spf13/cobra"
 )
@@ -30,50 +29,50 @@ rong video path/to/image.webp
 
 # Get generate colors as json
 rong video path/to/image.mp4 --dry-run --json | jq
+
+# Score colors of a image written to stdin
+rong score - < path/to/image.png
   `,
 	Args: cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		ctx := cmd.Context()
 
-		videoPath := args[0]
-
 		cwd, err := os.Getwd()
 		if err != nil {
 			return err
 		}
 
-		videoPath, err = pathutil.FindPath(cwd, videoPath)
+		src := media.Source{Path: media.Stdin, Weight: 1}
+		if args[0] != media.Stdin {
+			src.Path, err = pathutil.FindPath(cwd, args[0])
+			if err != nil {
+				return fmt.Errorf("failed to find image path: %w", err)
+			}
+		}
+
+		sources := []media.Source{src}
+		cleanup, err := media.ReadStdin(cmd.InOrStdin(), sources, "")
 		if err != nil {
-			return fmt.Errorf("failed to find image path: %w", err)
+			return err
 		}
+		defer cleanup()
+		src = sources[0]
 
-		slog.Info("Generating color", "from", videoPath)
+		slog.Info("Generating color", "from", src.Path)
 
-		hash, err := cache.Hash(videoPath)
+		m, err := media.Detect(ctx, src.Path)
 		if err != nil {
-			return fmt.Errorf("failed to get xxh sum: %w", err)
+			return err
 		}
 
-		quantized, err := cache.LoadCache(hash)
+		opts, err := media.GetOptions(ctx)
 		if err != nil {
-			if !os.IsNotExist(err) {
-				slog.Error("Failed to load cache", "error", err)
-			}
-
-			frames := config.FFmpegFrames.Value()
-			duration := config.FFmpegDuration.Value().Seconds()
-			pixels, err := ffmpeg.GetPixels(ctx, videoPath, frames, duration)
-			if err != nil {
-				return fmt.Errorf("failed to get pixels from media: %w", err)
-			}
-			quantized, err = material.Quantize(ctx, pixels)
-			if err != nil {
-				return err
-			}
+			return err
+		}
 
-			if err := cache.SaveCache(hash, quantized); err != nil {
-				slog.Warn("Failed to save colors to cache", "error", err)
-			}
+		quantized, _, err := cache.Quantize(ctx, src, m, opts)
+		if err != nil {
+			return err
 		}
 
 		slog.Info("Generating colors from source")
@@ -86,8 +85,8 @@ rong video path/to/image.mp4 --dry-run --json | jq
 			colors[i] = lab.ToARGB()
 		}
 
-		if config.MergeThreshold.Value() != 0 {
-			colors = mergeCloseColors(colors, config.MergeThreshold.Value())
+		if config.MergeThreshold.Value(ctx) != 0 {
+			colors = mergeCloseColors(colors, config.MergeThreshold.Value(ctx))
 		}
 
 		return json.NewEncoder(cmd.OutOrStdout()).Encode(colors)
@@ -1,25 +1,30 @@
 package video
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"log/slog"
 	"os"
 
 	"github.com/Nadim147c/rong/v5/internal/base16"
 	"github.com/Nadim147c/rong/v5/internal/cache"
 	"github.com/Nadim147c/rong/v5/internal/config"
-	"github.com/Nadim147c/rong/v5/internal/ffmpeg"
+	"github.com/Nadim147c/rong/v5/internal/daemon"
 	"github.com/Nadim147c/rong/v5/internal/material"
+	"github.com/Nadim147c/rong/v5/internal/media"
 	"github.com/Nadim147c/rong/v5/internal/models"
 	"github.com/Nadim147c/rong/v5/internal/pathutil"
+	"github.com/Nadim147c/rong/v5/internal/pick"
 	"github.com/Nadim147c/rong/v5/internal/templates"
 	"github.com/spf13/cobra"
 )
 
 // Command is the video command.
 var Command = &cobra.Command{
-	Use:   "video <video>",
+	Use:   "video <video[:weight]>...",
 	Short: "Generate colors from a video",
 	Example: `
 # Generate from a video
@@ -28,109 +33,146 @@ rong video path/to/video.mkv
 # Generate from a image
 rong video path/to/image.webp
 
+# Generate one theme from a video and an image with equal weight
+rong video path/to/video.mkv path/to/image.webp
+
+# Generate from a video written to stdin
+curl -s https://example.com/clip.webm | rong video -
+
 # Get generate colors as json
 rong video path/to/image.mp4 --dry-run --json | jq
   `,
-	Args: cobra.ExactArgs(1),
+	Args: cobra.MinimumNArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		ctx := cmd.Context()
+		ctx := pick.Interactive(cmd.Context())
 
-		videoPath := args[0]
+		if ok, err := daemon.Forward(ctx, cmd); ok {
+			return err
+		}
 
 		cwd, err := os.Getwd()
 		if err != nil {
 			return err
 		}
 
-		videoPath, err = pathutil.FindPath(cwd, videoPath)
+		sources, err := media.ParseSources(cwd, args)
 		if err != nil {
-			return fmt.Errorf("failed to find image path: %w", err)
+			return err
 		}
 
-		slog.Info("Generating color", "from", videoPath)
-
-		hash, err := cache.Hash(videoPath)
-		if err != nil {
-			return fmt.Errorf("failed to get xxh sum: %w", err)
+		dir := ""
+		if config.SaveSource.Value(ctx) && !config.DryRun.Value(ctx) {
+			dir = pathutil.StateDir
 		}
-
-		quantized, err := cache.LoadCache(hash)
+		cleanup, err := media.ReadStdin(cmd.InOrStdin(), sources, dir)
 		if err != nil {
-			if !os.IsNotExist(err) {
-				slog.Error("Failed to load cache", "error", err)
-			}
-
-			frames := config.FFmpegFrames.Value()
-			duration := config.FFmpegDuration.Value().Seconds()
-			pixels, err := ffmpeg.GetPixels(ctx, videoPath, frames, duration)
-			if err != nil {
-				return fmt.Errorf("failed to get pixels from media: %w", err)
-			}
-			quantized, err = material.Quantize(ctx, pixels)
-			if err != nil {
-				return err
-			}
-
-			if err := cache.SaveCache(hash, quantized); err != nil {
-				slog.Warn("Failed to save colors to cache", "error", err)
-			}
+			return err
 		}
+		defer cleanup()
 
-		slog.Info("Generating colors from source")
-
-		cfg := material.GetConfig()
+		return Generate(ctx, cmd.OutOrStdout(), sources...)
+	},
+}
 
-		colorMap, err := material.GenerateFromQuantized(quantized, cfg, config.SourceColor.Value())
+// Generate generates colors from media and executes templates. Colors of
+// several sources are merged by their weight. Output requested by flags (json,
+// inline template) is written to w.
+func Generate(ctx context.Context, w io.Writer, sources ...media.Source) error {
+	if len(sources) == 0 {
+		return errors.New("no media to generate colors from")
+	}
+
+	opts, err := media.GetOptions(ctx)
+	if err != nil {
+		return err
+	}
+
+	all := make([]material.Quantized, len(sources))
+	weights := make([]float64, len(sources))
+	states := make([]cache.Source, len(sources))
+	var first media.Media
+	for i, src := range sources {
+		slog.Info("Generating color", "from", src.Path, "weight", src.Weight)
+
+		m, err := media.Detect(ctx, src.Path)
 		if err != nil {
-			return fmt.Errorf("failed to generate colors: %w", err)
+			return err
+		}
+		if i == 0 {
+			first = m
 		}
 
-		customs, err := material.GenerateCustomColors(colorMap["primary"])
+		q, hash, err := cache.Quantize(ctx, src, m, opts)
 		if err != nil {
 			return err
 		}
+		all[i], weights[i] = q, src.Weight
+		states[i] = cache.Source{Path: src.Name(), Hash: hash, Weight: src.Weight}
+	}
+	quantized := material.Merge(all, weights)
+
+	slog.Info("Generating colors from source")
+
+	cfg := material.GetConfig(ctx)
+
+	source, err := pick.Source(ctx, quantized, cfg)
+	if err != nil {
+		return err
+	}
+
+	colorMap, err := material.GenerateFromQuantized(quantized, cfg, source)
+	if err != nil {
+		return fmt.Errorf("failed to generate colors: %w", err)
+	}
+
+	customs, err := material.GenerateCustomColors(ctx, colorMap["primary"])
+	if err != nil {
+		return err
+	}
 
-		based := base16.Generate(colorMap, quantized)
+	based := base16.Generate(ctx, colorMap, quantized)
 
-		path, err := cache.GetPreview(videoPath, hash)
+	path := states[0].Path
+	if first.IsVideo() {
+		path, err = cache.GetPreview(ctx, first.Path, states[0].Hash)
 		if err != nil {
 			slog.Warn("Failed to generate preview image", "error", err)
-			path = videoPath
+			path = states[0].Path
 		} else {
 			slog.Info("Using generated preview", "path", path)
 		}
+	}
 
-		output := models.NewOutput(path, based, colorMap, customs)
+	output := models.NewOutput(ctx, path, based, colorMap, customs)
 
-		if config.JSON.Value() {
-			err := json.NewEncoder(cmd.OutOrStdout()).Encode(output)
-			if err != nil {
-				slog.Error("Failed to encode output", "error", err)
-			}
+	if config.JSON.Value(ctx) {
+		err := json.NewEncoder(w).Encode(output)
+		if err != nil {
+			slog.Error("Failed to encode output", "error", err)
 		}
+	}
 
-		if config.SimpleJSON.Value() {
-			err := models.WriteSimpleJSON(cmd.OutOrStdout(), output)
-			if err != nil {
-				slog.Error("Failed to encode output", "error", err)
-			}
+	if config.SimpleJSON.Value(ctx) {
+		err := models.WriteSimpleJSON(w, output)
+		if err != nil {
+			slog.Error("Failed to encode output", "error", err)
 		}
+	}
 
-		if tmpl := config.Template.Value(); tmpl != "" {
-			err := templates.ExecuteInline(tmpl, output, cmd.OutOrStdout())
-			if err != nil {
-				slog.Error("Failed to execute inline template", "error", err)
-			}
+	if tmpl := config.Template.Value(ctx); tmpl != "" {
+		err := templates.ExecuteInline(tmpl, output, w)
+		if err != nil {
+			slog.Error("Failed to execute inline template", "error", err)
 		}
+	}
 
-		if config.DryRun.Value() {
-			return nil
-		}
+	if config.DryRun.Value(ctx) {
+		return nil
+	}
 
-		if err := cache.SaveState(videoPath, hash, quantized); err != nil {
-			slog.Warn("Failed to save colors to cache", "error", err)
-		}
+	if err := cache.SaveState(states, quantized); err != nil {
+		slog.Warn("Failed to save colors to cache", "error", err)
+	}
 
-		return templates.Execute(ctx, output)
-	},
+	return templates.Execute(ctx, output)
 }
@@ -0,0 +1,193 @@
+package watch
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/Nadim147c/rong/v5/cmd/regen"
+	"github.com/Nadim147c/rong/v5/internal/config"
+	"github.com/Nadim147c/rong/v5/internal/pathutil"
+	"github.com/fsnotify/fsnotify"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func init() {
+	config.WatchDebounce.RegisterFlag(Command.Flags())
+}
+
+// Command is the watch command.
+var Command = &cobra.Command{
+	Use:   "watch [flags]",
+	Short: "Regenerate colors when templates or configuration change",
+	Example: `
+# Regenerate on every template or config change
+rong watch
+
+# Wait longer for editors that write files in several steps
+rong watch --watch.debounce 1s
+  `,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		ctx := cmd.Context()
+
+		watcher, err := fsnotify.NewWatcher()
+		if err != nil {
+			return fmt.Errorf("failed to create file watcher: %w", err)
+		}
+		defer watcher.Close()
+
+		w := newWatchList(watcher)
+
+		templateRoot := filepath.Join(pathutil.ConfigDir, "templates")
+		if err := os.MkdirAll(templateRoot, 0o750); err != nil {
+			return fmt.Errorf("failed to create template directory: %w", err)
+		}
+		if err := w.addTree(templateRoot); err != nil {
+			return err
+		}
+
+		configFile := viper.ConfigFileUsed()
+		if configFile != "" {
+			configFile, err = filepath.Abs(configFile)
+			if err != nil {
+				return fmt.Errorf("failed to find config path: %w", err)
+			}
+			// Editors often replace the file instead of writing to it, which
+			// drops the watch on the file. Watching the parent directory
+			// survives that.
+			if err := w.add(filepath.Dir(configFile)); err != nil {
+				return err
+			}
+		}
+
+		out, stderr := cmd.OutOrStdout(), cmd.ErrOrStderr()
+		run(cmd, out, stderr, false)
+
+		debounce := config.WatchDebounce.Value(ctx)
+		timer := time.NewTimer(debounce)
+		timer.Stop()
+
+		var reload bool
+		for {
+			select {
+			case <-ctx.Done():
+				return nil
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return nil
+				}
+				slog.Error("File watcher error", "error", err)
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return nil
+				}
+
+				if event.Has(fsnotify.Chmod) {
+					continue
+				}
+
+				switch {
+				case configFile != "" && filepath.Clean(event.Name) == configFile:
+					reload = true
+				case within(templateRoot, event.Name):
+					if event.Has(fsnotify.Create) {
+						if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+							if err := w.addTree(event.Name); err != nil {
+								slog.Error("Failed to watch directory", "path", event.Name, "error", err)
+							}
+						}
+					}
+				default:
+					continue
+				}
+
+				slog.Debug("File changed", "path", event.Name, "op", event.Op.String())
+				timer.Reset(debounce)
+			case <-timer.C:
+				run(cmd, out, stderr, reload)
+				reload = false
+			}
+		}
+	},
+}
+
+// run regenerates colors and prints the result without stopping the watcher.
+func run(cmd *cobra.Command, out, stderr io.Writer, reload bool) {
+	stamp := time.Now().Format(time.TimeOnly)
+
+	if reload {
+		if err := viper.ReadInConfig(); err != nil {
+			fmt.Fprintf(stderr, "%s failed to reload config: %v\n", stamp, err)
+			return
+		}
+		slog.Info("Configuration reloaded", "path", viper.ConfigFileUsed())
+	}
+
+	if err := regen.Regenerate(cmd.Context(), out); err != nil {
+		fmt.Fprintf(stderr, "%s %v\n", stamp, err)
+		return
+	}
+
+	fmt.Fprintf(stderr, "%s regenerated\n", stamp)
+}
+
+// watchList keeps track of directories added to the watcher.
+type watchList struct {
+	watcher *fsnotify.Watcher
+	dirs    map[string]struct{}
+}
+
+func newWatchList(watcher *fsnotify.Watcher) *watchList {
+	return &watchList{watcher: watcher, dirs: map[string]struct{}{}}
+}
+
+func (w *watchList) add(dir string) error {
+	dir = filepath.Clean(dir)
+	if _, ok := w.dirs[dir]; ok {
+		return nil
+	}
+	if err := w.watcher.Add(dir); err != nil {
+		return fmt.Errorf("failed to watch %q: %w", dir, err)
+	}
+	w.dirs[dir] = struct{}{}
+	slog.Info("Watching directory", "path", dir)
+	return nil
+}
+
+// addTree watches root and all directories below it, so that partials kept in
+// sub-directories are also watched.
+func (w *watchList) addTree(root string) error {
+	var errs []error
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			errs = append(errs, err)
+			return nil
+		}
+		if d.IsDir() {
+			if err := w.add(path); err != nil {
+				errs = append(errs, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+// within reports whether path is inside root.
+func within(root, path string) bool {
+	rel, err := filepath.Rel(root, path)
+	if err != nil {
+		return false
+	}
+	return rel == "." || filepath.IsLocal(rel)
+}
@@ -105,7 +105,7 @@ func TestConfig(t *testing.T) {
 
 			for key, want := range tt.config {
 				rv := reflect.ValueOf(key)
-				got := rv.MethodByName("Value").Call(nil)[0].Interface()
+				got := rv.MethodByName("Value").Call([]reflect.Value{reflect.ValueOf(t.Context())})[0].Interface()
 				t.Log(want, got)
 				if !reflect.DeepEqual(got, want) {
 					t.Errorf("viper config and watned config did not match: want=%v, got=%v", want, got)
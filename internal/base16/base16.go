@@ -1,6 +1,8 @@
 package base16
 
 import (
+	"context"
+
 	"github.com/Nadim147c/material/v3/color"
 	"github.com/Nadim147c/rong/v5/internal/config"
 	"github.com/Nadim147c/rong/v5/internal/config/enums"
@@ -9,15 +11,16 @@ import (
 
 // Generate generates colors from material color name and quantized colors.
 func Generate(
+	ctx context.Context,
 	material map[string]color.ARGB,
 	quantized material.Quantized,
 ) Base16 {
-	switch config.Base16Method.Value() {
+	switch config.Base16Method.Value(ctx) {
 	case enums.Base16MethodStatic:
-		return GenerateStatic(material["primary"], quantized.Wu)
+		return GenerateStatic(ctx, material["primary"], quantized.Wu)
 	case enums.Base16MethodDynamic:
 		fg, bg := material["on_background"], material["background"]
-		return GenerateDynamic(fg, bg, quantized.Wu)
+		return GenerateDynamic(ctx, fg, bg, quantized.Wu)
 	default:
 		panic("unreachable")
 	}
@@ -36,9 +39,9 @@ type Base16 struct {
 	White, BrightWhite     color.ARGB
 }
 
-// NewBase16 creates a new Base16.
-func NewBase16() Base16 {
-	return Base16{dark: config.Dark.Value()}
+// NewBase16 creates a new Base16 for the theme of ctx.
+func NewBase16(ctx context.Context) Base16 {
+	return Base16{dark: config.Dark.Value(ctx)}
 }
 
 // SetBlack sets the Black and Bright Black color.
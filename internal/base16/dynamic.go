@@ -1,6 +1,7 @@
 package base16
 
 import (
+	"context"
 	"log/slog"
 	"math"
 	"math/rand"
@@ -14,6 +15,7 @@ import (
 // GenerateDynamic generates base16 colors from selecting quantizes color. It
 // takes color with long chroma distance to ensure colors has more variety.
 func GenerateDynamic(
+	ctx context.Context,
 	fg, bg color.ARGB,
 	colors []color.ARGB,
 ) Base16 {
@@ -22,9 +24,9 @@ func GenerateDynamic(
 		hct[i] = v.ToHct()
 	}
 
-	selected := SelectColors(ensureHueVariety(hct), 6)
+	selected := SelectColors(ensureHueVariety(ctx, hct), 6)
 
-	based := NewBase16()
+	based := NewBase16(ctx)
 	based.SetWhite(fg.ToHct())
 	based.SetBlack(bg.ToHct())
 
@@ -67,9 +69,9 @@ func hueSpread(colors []color.Hct) float64 {
 	return maxHue - minHue
 }
 
-func ensureHueVariety(colors []color.Hct) []color.Hct {
+func ensureHueVariety(ctx context.Context, colors []color.Hct) []color.Hct {
 	if len(colors) == 0 {
-		return []color.Hct{config.Base16Red.Value().ToHct()}
+		return []color.Hct{config.Base16Red.Value(ctx).ToHct()}
 	}
 
 	out := make([]color.Hct, len(colors))
@@ -2,6 +2,7 @@ package base16
 
 import (
 	"cmp"
+	"context"
 	"math"
 	"slices"
 
@@ -10,20 +11,20 @@ import (
 )
 
 // GenerateStatic generates base16 colors from pre-defined colors.
-func GenerateStatic(primary color.ARGB, wu []color.ARGB) Base16 {
-	ratio := config.Base16Blend.Value()
+func GenerateStatic(ctx context.Context, primary color.ARGB, wu []color.ARGB) Base16 {
+	ratio := config.Base16Blend.Value(ctx)
 
 	blend := makeBlendFunc(ratio, primary, wu)
-	black := blend(config.Base16Black.Value())
-	red := blend(config.Base16Red.Value())
-	green := blend(config.Base16Green.Value())
-	yellow := blend(config.Base16Yellow.Value())
-	blue := blend(config.Base16Blue.Value())
-	magenta := blend(config.Base16Magenta.Value())
-	cyan := blend(config.Base16Cyan.Value())
-	white := blend(config.Base16White.Value())
+	black := blend(config.Base16Black.Value(ctx))
+	red := blend(config.Base16Red.Value(ctx))
+	green := blend(config.Base16Green.Value(ctx))
+	yellow := blend(config.Base16Yellow.Value(ctx))
+	blue := blend(config.Base16Blue.Value(ctx))
+	magenta := blend(config.Base16Magenta.Value(ctx))
+	cyan := blend(config.Base16Cyan.Value(ctx))
+	white := blend(config.Base16White.Value(ctx))
 
-	based := NewBase16()
+	based := NewBase16(ctx)
 	based.SetWhite(white)
 	based.SetBlack(black)
 	based.SetRed(red)
@@ -0,0 +1,328 @@
+package cache
+
+import (
+	"archive/tar"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/Nadim147c/rong/v5/internal/pathutil"
+	"github.com/google/renameio/v2"
+	"github.com/klauspost/compress/zstd"
+)
+
+const (
+	manifestName    = "manifest.json"
+	manifestVersion = 1
+)
+
+// ErrInvalidBundle means a bundle is not a rong cache bundle.
+var ErrInvalidBundle = errors.New("invalid cache bundle")
+
+// bundleFile matches names of cache files in a bundle.
+var bundleFile = regexp.MustCompile(`^[0-9a-f]+(\.[0-9a-z]+)+$`)
+
+// manifest is the first file of a bundle and lists its entries.
+type manifest struct {
+	Version int             `json:"version"`
+	Created time.Time       `json:"created"`
+	Entries []manifestEntry `json:"entries"`
+}
+
+// manifestEntry describes the source of an entry without its path.
+type manifestEntry struct {
+	Key  string `json:"key"`
+	Name string `json:"name"`
+	Size int64  `json:"size"`
+}
+
+// ImportResult summarizes Import.
+type ImportResult struct {
+	Imported int `json:"imported"`
+	Matched  int `json:"matched"`
+	Skipped  int `json:"skipped"`
+}
+
+// Export writes the cache entries of the given file paths or cache keys to w
+// as a zstd compressed tar bundle. All entries with a source and imported
+// entries are exported when no argument is given. Source paths are left out of
+// the bundle. It returns the number of exported entries.
+func Export(w io.Writer, args []string) (int, error) {
+	var entries []Entry
+	if len(args) == 0 {
+		all, err := Entries()
+		if err != nil {
+			return 0, err
+		}
+		for _, entry := range all {
+			if entry.live() {
+				entries = append(entries, entry)
+			}
+		}
+	} else {
+		for _, arg := range args {
+			entry, err := resolve(arg)
+			if err != nil {
+				return 0, err
+			}
+			entries = append(entries, entry)
+		}
+	}
+
+	m := manifest{Version: manifestVersion, Created: time.Now()}
+	for _, entry := range entries {
+		me := manifestEntry{Key: entry.Key}
+		if len(entry.Sources) != 0 {
+			me.Name = filepath.Base(entry.Sources[0])
+			if info, err := os.Stat(entry.Sources[0]); err == nil {
+				me.Size = info.Size()
+			}
+		}
+		m.Entries = append(m.Entries, me)
+	}
+
+	zw, err := zstd.NewWriter(w)
+	if err != nil {
+		return 0, err
+	}
+	tw := tar.NewWriter(zw)
+
+	data, err := json.Marshal(m)
+	if err != nil {
+		return 0, err
+	}
+	hdr := &tar.Header{Name: manifestName, Mode: 0o640, Size: int64(len(data)), ModTime: m.Created}
+	if err := tw.WriteHeader(hdr); err != nil {
+		return 0, err
+	}
+	if _, err := tw.Write(data); err != nil {
+		return 0, err
+	}
+
+	for _, entry := range entries {
+		for _, file := range entry.Files {
+			add := addFile
+			if file == metaPath(entry.Key) {
+				add = addMeta
+			}
+			if err := add(tw, file); err != nil {
+				return 0, fmt.Errorf("failed to add %s: %w", file, err)
+			}
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return 0, err
+	}
+	return len(entries), zw.Close()
+}
+
+func addFile(tw *tar.Writer, path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+
+	hdr, err := tar.FileInfoHeader(info, "")
+	if err != nil {
+		return err
+	}
+	if err := tw.WriteHeader(hdr); err != nil {
+		return err
+	}
+
+	_, err = io.Copy(tw, file)
+	return err
+}
+
+// addMeta adds the metadata at path without the path of its source, which
+// belongs to this machine only.
+func addMeta(tw *tar.Writer, path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var meta Meta
+	if err := json.Unmarshal(data, &meta); err != nil {
+		return err
+	}
+	meta.Source, meta.Preview = "", ""
+	if data, err = json.Marshal(meta); err != nil {
+		return err
+	}
+
+	hdr, err := tar.FileInfoHeader(info, "")
+	if err != nil {
+		return err
+	}
+	hdr.Size = int64(len(data))
+	if err := tw.WriteHeader(hdr); err != nil {
+		return err
+	}
+	_, err = tw.Write(data)
+	return err
+}
+
+// Import reads a bundle written by Export from r. Entries are matched to local
+// files by content, so paths don't need to be the same as on the exporting
+// machine. When locals is not empty, only entries matching one of the local
+// files are imported. Entries that are already cached are skipped.
+func Import(r io.Reader, locals []string) (ImportResult, error) {
+	var result ImportResult
+
+	zr, err := zstd.NewReader(r)
+	if err != nil {
+		return result, err
+	}
+	defer zr.Close()
+	tr := tar.NewReader(zr)
+
+	hdr, err := tr.Next()
+	if err != nil || hdr.Name != manifestName {
+		return result, fmt.Errorf("%w: missing manifest", ErrInvalidBundle)
+	}
+	var m manifest
+	if err := json.NewDecoder(tr).Decode(&m); err != nil {
+		return result, fmt.Errorf("%w: %w", ErrInvalidBundle, err)
+	}
+	if m.Version != manifestVersion {
+		return result, fmt.Errorf("%w: unsupported version %d", ErrInvalidBundle, m.Version)
+	}
+
+	wanted := map[string]bool{}
+	for _, entry := range m.Entries {
+		wanted[entry.Key] = len(locals) == 0
+	}
+	matched := matchLocals(m.Entries, locals)
+	for key := range matched {
+		wanted[key] = true
+	}
+
+	if err := os.MkdirAll(pathutil.CacheDir, 0o750); err != nil {
+		return result, err
+	}
+
+	imported := map[string]bool{}
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return result, err
+		}
+
+		name := hdr.Name
+		key, _, _ := strings.Cut(name, ".")
+		if hdr.Typeflag != tar.TypeReg || !bundleFile.MatchString(name) {
+			slog.Warn("Skipping unexpected file in bundle", "name", name)
+			continue
+		}
+		if !wanted[key] {
+			continue
+		}
+
+		dst := filepath.Join(pathutil.CacheDir, name)
+		if _, err := os.Stat(dst); err == nil {
+			continue
+		}
+
+		if err := extract(tr, dst); err != nil {
+			return result, err
+		}
+		imported[key] = true
+	}
+
+	for key, source := range matched {
+		meta, err := Show(key)
+		if err != nil {
+			continue
+		}
+		meta.Source, meta.Imported = source, false
+		if err := writeMeta(meta); err != nil {
+			slog.Warn("Failed to update cache metadata", "key", key, "error", err)
+		}
+	}
+
+	// Entries without a local file keep the metadata of the exporting
+	// machine, which must not be mistaken for a local source.
+	for key := range imported {
+		if _, ok := matched[key]; ok {
+			continue
+		}
+		meta, err := readMeta(key)
+		if err != nil {
+			meta = Meta{Key: key, CachedAt: time.Now()}
+		}
+		meta.Imported = true
+		if err := writeMeta(meta); err != nil {
+			slog.Warn("Failed to update cache metadata", "key", key, "error", err)
+		}
+	}
+
+	result.Imported = len(imported)
+	result.Matched = len(matched)
+	result.Skipped = len(m.Entries) - result.Imported
+	return result, nil
+}
+
+// extract writes the current file of tr to dst. The file is streamed to a
+// temporary file first, so dst is complete or missing.
+func extract(tr *tar.Reader, dst string) error {
+	file, err := renameio.NewPendingFile(dst, renameio.WithPermissions(0o640))
+	if err != nil {
+		return err
+	}
+	defer file.Cleanup()
+
+	if _, err := io.Copy(file, tr); err != nil {
+		return err
+	}
+	return file.CloseAtomicallyReplace()
+}
+
+// matchLocals hashes local files with the size of a bundle entry and returns
+// the local path of every matching entry.
+func matchLocals(entries []manifestEntry, locals []string) map[string]string {
+	sizes := map[int64]bool{}
+	keys := map[string]bool{}
+	for _, entry := range entries {
+		sizes[entry.Size] = true
+		keys[entry.Key] = true
+	}
+
+	matched := map[string]string{}
+	for _, local := range locals {
+		info, err := os.Stat(local)
+		if err != nil || !sizes[info.Size()] {
+			continue
+		}
+		key, err := Hash(local)
+		if err != nil {
+			slog.Warn("Failed to hash file", "path", local, "error", err)
+			continue
+		}
+		if keys[key] {
+			matched[key] = local
+		}
+	}
+	return matched
+}
@@ -0,0 +1,90 @@
+package cache
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestBundleFile(t *testing.T) {
+	testdata := []struct {
+		name string
+		file string
+		want bool
+	}{
+		{"colors", "3f9a1c.json", true},
+		{"variant", "3f9a1c.0123456789abcdef.json", true},
+		{"metadata", "3f9a1c.meta.json", true},
+		{"preview", "3f9a1c.webp", true},
+		{"no extension", "3f9a1c", false},
+		{"upper case key", "3F9A1C.json", false},
+		{"not a key", "manifest.json", false},
+		{"empty extension", "3f9a1c..json", false},
+		{"trailing dot", "3f9a1c.json.", false},
+		{"directory", "3f9a1c/a.json", false},
+		{"parent directory", "../3f9a1c.json", false},
+		{"absolute", "/3f9a1c.json", false},
+		{"hidden", ".3f9a1c.json", false},
+	}
+
+	for _, test := range testdata {
+		t.Run(test.name, func(t *testing.T) {
+			if got := bundleFile.MatchString(test.file); got != test.want {
+				t.Errorf("bundleFile.MatchString(%q) = %v, want %v", test.file, got, test.want)
+			}
+		})
+	}
+}
+
+func TestBundleRoundTrip(t *testing.T) {
+	useCacheDir(t)
+	key := cacheSource(t, filepath.Join(t.TempDir(), "a.png"), "a")
+
+	var bundle bytes.Buffer
+	n, err := Export(&bundle, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 1 {
+		t.Fatalf("exported %d entries, want 1", n)
+	}
+
+	useCacheDir(t)
+	result, err := Import(&bundle, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Imported != 1 {
+		t.Fatalf("imported %d entries, want 1", result.Imported)
+	}
+
+	meta, err := readMeta(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if meta.Source != "" || !meta.Imported {
+		t.Errorf("imported metadata has source %q and imported %v", meta.Source, meta.Imported)
+	}
+
+	entries, err := Entries()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || !entries[0].live() {
+		t.Fatalf("imported entries %+v are not live", entries)
+	}
+
+	pruned, err := Prune(PruneOptions{MaxAge: time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pruned) != 0 {
+		t.Errorf("pruned imported entries %+v", pruned)
+	}
+
+	bundle.Reset()
+	if n, err := Export(&bundle, nil); err != nil || n != 1 {
+		t.Errorf("exported %d imported entries, %v, want 1", n, err)
+	}
+}
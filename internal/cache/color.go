@@ -1,46 +1,120 @@
 package cache
 
 import (
-	"crypto/md5" //nolint:gosec
-	"encoding/hex"
+	"container/list"
+	"context"
 	"encoding/json"
+	"errors"
+	"io/fs"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync"
 
-	"github.com/Nadim147c/rong/v5/internal/config"
 	"github.com/Nadim147c/rong/v5/internal/material"
 	"github.com/Nadim147c/rong/v5/internal/pathutil"
 )
 
-// Hash returns md5 sum.
-func Hash(path string) (string, error) {
-	name, err := filepath.Abs(path)
+// IsCached checks if the colors of key are cached or not. Videos also need a
+// preview of their content.
+func IsCached(ctx context.Context, key string, isVideo bool) bool {
+	jsonCache := filepath.Join(pathutil.CacheDir, key+".json")
+	if _, err := os.Stat(jsonCache); err != nil {
+		return false
+	}
+	if !isVideo {
+		return true
+	}
+	p, err := previewOptions(ctx)
 	if err != nil {
-		return "", err
+		return false
 	}
-	b := md5.Sum([]byte(name)) //nolint:gosec
-	return hex.EncodeToString(b[:]), nil
+	_, err = os.Stat(previewPath(entryKey(key), p))
+	return err == nil
 }
 
-// IsCached checks if the file is colors is cached or not.
-func IsCached(hash string, isVideo bool) bool {
-	if !isVideo {
-		jsonCache := filepath.Join(pathutil.CacheDir, hash+".json")
-		_, err := os.Stat(jsonCache)
-		return err == nil
+// memoryLimit is the maximum number of quantized colors kept in memory.
+const memoryLimit = 512
+
+// memory keeps recently used quantized colors of long-running processes in
+// memory.
+var memory struct {
+	sync.Mutex
+	colors map[string]*list.Element
+	// recent has the most recently used colors first.
+	recent *list.List
+}
+
+// remembered is quantized colors kept in memory.
+type remembered struct {
+	hash   string
+	colors material.Quantized
+}
+
+// KeepInMemory makes LoadCache and SaveCache keep the quantized colors used
+// most recently in memory, so repeated lookups don't read the cache dir again.
+func KeepInMemory() {
+	memory.Lock()
+	defer memory.Unlock()
+	if memory.colors == nil {
+		memory.colors = map[string]*list.Element{}
+		memory.recent = list.New()
 	}
-	format := config.PreviewFormat.Value()
-	preview := filepath.Join(pathutil.CacheDir, hash+"."+format.String())
-	_, err := os.Stat(preview)
-	return err == nil
+}
+
+func remember(hash string, output material.Quantized) {
+	memory.Lock()
+	defer memory.Unlock()
+	if memory.colors == nil {
+		return
+	}
+
+	if e, ok := memory.colors[hash]; ok {
+		e.Value.(*remembered).colors = output
+		memory.recent.MoveToFront(e)
+		return
+	}
+
+	memory.colors[hash] = memory.recent.PushFront(&remembered{hash, output})
+	if memory.recent.Len() > memoryLimit {
+		oldest := memory.recent.Remove(memory.recent.Back()).(*remembered)
+		delete(memory.colors, oldest.hash)
+	}
+}
+
+// recall returns the colors of hash kept in memory.
+func recall(hash string) (material.Quantized, bool) {
+	memory.Lock()
+	defer memory.Unlock()
+	e, ok := memory.colors[hash]
+	if !ok {
+		return material.Quantized{}, false
+	}
+	memory.recent.MoveToFront(e)
+	return e.Value.(*remembered).colors, true
 }
 
 // LoadCache tries to load cached colors for this image.
 func LoadCache(hash string) (material.Quantized, error) {
+	if output, ok := recall(hash); ok {
+		return output, nil
+	}
+
+	output, err := readCache(hash)
+	if err != nil {
+		return output, err
+	}
+
+	touch(filepath.Join(pathutil.CacheDir, hash+".json"))
+	remember(hash, output)
+	return output, nil
+}
+
+// readCache reads cached colors without marking them as used.
+func readCache(hash string) (material.Quantized, error) {
 	var output material.Quantized
-	path := filepath.Join(pathutil.CacheDir, hash+".json")
 
-	file, err := os.Open(path)
+	file, err := os.Open(filepath.Join(pathutil.CacheDir, hash+".json"))
 	if err != nil {
 		return output, err
 	}
@@ -50,19 +124,57 @@ func LoadCache(hash string) (material.Quantized, error) {
 	return output, err
 }
 
-// SaveCache saves output colors to cache dir.
-func SaveCache(hash string, output material.Quantized) error {
-	path := filepath.Join(pathutil.CacheDir, hash+".json")
+// readEntry reads the cached colors of entry, or of one of its variants when
+// the content was only cached with non-default options.
+func readEntry(entry Entry) (material.Quantized, error) {
+	output, err := readCache(entry.Key)
+	if !errors.Is(err, fs.ErrNotExist) {
+		return output, err
+	}
+	for _, file := range entry.Files {
+		name := filepath.Base(file)
+		if key, ok := strings.CutSuffix(name, ".json"); ok && key != entry.Key && !strings.HasSuffix(key, ".meta") {
+			return readCache(key)
+		}
+	}
+	return output, err
+}
+
+// SaveCache saves output colors quantized from source to cache dir.
+func SaveCache(source, hash string, output material.Quantized) error {
+	return save(source, source, hash, output)
+}
+
+// SaveStdinCache saves output colors quantized from the copy of standard input
+// at path to cache dir. The source of the entry is recorded as "-", since the
+// copy doesn't outlive the command.
+func SaveStdinCache(path, hash string, output material.Quantized) error {
+	return save(path, "-", hash, output)
+}
+
+// save saves output colors quantized from the file at path. source is the
+// source of the entry recorded in its metadata.
+func save(path, source, hash string, output material.Quantized) error {
+	remember(hash, output)
 
 	if err := os.MkdirAll(pathutil.CacheDir, 0o750); err != nil {
 		return err
 	}
 
-	file, err := os.Create(path)
+	file, err := os.Create(filepath.Join(pathutil.CacheDir, hash+".json"))
 	if err != nil {
 		return err
 	}
 	defer file.Close()
 
-	return json.NewEncoder(file).Encode(output)
+	if err := json.NewEncoder(file).Encode(output); err != nil {
+		return err
+	}
+
+	// Metadata is shared by variants, the default colors describe it best.
+	key := entryKey(hash)
+	if _, err := os.Stat(metaPath(key)); err == nil && key != hash {
+		return nil
+	}
+	return saveMeta(path, source, key, output)
 }
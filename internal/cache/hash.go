@@ -0,0 +1,210 @@
+package cache
+
+import (
+	"crypto/md5" //nolint:gosec
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Nadim147c/rong/v5/internal/pathutil"
+	"github.com/google/renameio/v2"
+	"github.com/zeebo/xxh3"
+)
+
+// chunkSize is the size of the chunks hashed from large files.
+const chunkSize = 1 << 20
+
+// indexDir contains the key of every known source path.
+var indexDir = filepath.Join(pathutil.CacheDir, "index")
+
+// pathEntry maps a source path to the key of its content at the time it was
+// hashed.
+type pathEntry struct {
+	Path    string    `json:"path"`
+	Key     string    `json:"key"`
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"mod_time"`
+}
+
+// Hash returns the cache key of the file at path. The key is computed from the
+// file content, so moved or renamed files keep their cache and files replaced
+// in place get a new one. Keys are remembered per path and reused as long as
+// the size and modification time of the file don't change.
+func Hash(path string) (string, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+
+	info, err := os.Stat(abs)
+	if err != nil {
+		return "", err
+	}
+
+	entry, err := loadPathEntry(abs)
+	if err == nil && entry.Size == info.Size() && entry.ModTime.Equal(info.ModTime()) {
+		return entry.Key, nil
+	}
+	known := err == nil
+
+	key, err := contentHash(abs)
+	if err != nil {
+		return "", err
+	}
+
+	// Caches created before content keys are keyed by the path. Those are
+	// only valid if the file hasn't changed since.
+	if !known {
+		migrate(pathHash(abs), key, info.ModTime())
+	}
+
+	entry = pathEntry{abs, key, info.Size(), info.ModTime()}
+	if err := savePathEntry(entry); err != nil {
+		slog.Warn("Failed to save cache index", "path", abs, "error", err)
+	}
+
+	return key, nil
+}
+
+// HashContent returns the cache key of the file at path like Hash without
+// remembering the path. It is meant for files that don't outlive the command,
+// like copies of standard input.
+func HashContent(path string) (string, error) {
+	return contentHash(path)
+}
+
+// Variant returns the key of colors decoded from the content of hash with
+// options identified by variant. Variants are stored in the entry of their
+// content, so they are listed, exported and pruned together with it. An empty
+// variant returns hash.
+func Variant(hash, variant string) string {
+	if variant == "" {
+		return hash
+	}
+	return fmt.Sprintf("%s.%016x", hash, xxh3.HashString(variant))
+}
+
+// entryKey returns the key of the entry a cache key or variant belongs to.
+func entryKey(key string) string {
+	key, _, _ = strings.Cut(key, ".")
+	return key
+}
+
+// contentHash returns xxh3 sum of the size and content of the file. Files
+// larger than three chunks are sampled at the beginning, middle and end.
+func contentHash(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return "", err
+	}
+	size := info.Size()
+
+	h := xxh3.New()
+	if err := binary.Write(h, binary.LittleEndian, size); err != nil {
+		return "", err
+	}
+
+	if size <= 3*chunkSize {
+		if _, err := io.Copy(h, file); err != nil {
+			return "", err
+		}
+	} else {
+		for _, offset := range []int64{0, size/2 - chunkSize/2, size - chunkSize} {
+			chunk := io.NewSectionReader(file, offset, chunkSize)
+			if _, err := io.Copy(h, chunk); err != nil {
+				return "", err
+			}
+		}
+	}
+
+	sum := h.Sum128().Bytes()
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// pathHash returns md5 sum of the path. It was used as cache key before keys
+// were computed from content, and still names the index entry of the path.
+func pathHash(abs string) string {
+	b := md5.Sum([]byte(abs)) //nolint:gosec
+	return hex.EncodeToString(b[:])
+}
+
+func loadPathEntry(abs string) (pathEntry, error) {
+	var entry pathEntry
+
+	data, err := os.ReadFile(filepath.Join(indexDir, pathHash(abs)+".json"))
+	if err != nil {
+		return entry, err
+	}
+
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return entry, err
+	}
+
+	if entry.Path != abs {
+		return entry, os.ErrNotExist
+	}
+	return entry, nil
+}
+
+func savePathEntry(entry pathEntry) error {
+	if err := os.MkdirAll(indexDir, 0o750); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	path := filepath.Join(indexDir, pathHash(entry.Path)+".json")
+	return renameio.WriteFile(path, data, 0o640)
+}
+
+// migrate renames cache files named after legacy key to key. Files older than
+// modTime of the source were created from a previous content of the source and
+// are removed instead.
+func migrate(legacy, key string, modTime time.Time) {
+	matches, err := filepath.Glob(filepath.Join(pathutil.CacheDir, legacy+".*"))
+	if err != nil {
+		return
+	}
+
+	for _, old := range matches {
+		info, err := os.Stat(old)
+		if err != nil {
+			continue
+		}
+		if modTime.After(info.ModTime()) {
+			if err := os.Remove(old); err != nil {
+				slog.Warn("Failed to remove stale cache", "path", old, "error", err)
+				continue
+			}
+			slog.Info("Discarded legacy cache older than its source", "path", old)
+			continue
+		}
+
+		ext := strings.TrimPrefix(filepath.Base(old), legacy)
+		dst := filepath.Join(pathutil.CacheDir, key+ext)
+		if _, err := os.Stat(dst); err == nil {
+			continue
+		}
+		if err := os.Rename(old, dst); err != nil {
+			slog.Warn("Failed to migrate cache", "src", old, "dst", dst, "error", err)
+			continue
+		}
+		slog.Info("Migrated cache to content key", "src", old, "dst", dst)
+	}
+}
@@ -0,0 +1,100 @@
+package cache
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Nadim147c/rong/v5/internal/pathutil"
+)
+
+// useCacheDir points the cache of the test to a temporary directory.
+func useCacheDir(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	cacheDir, index := pathutil.CacheDir, indexDir
+	pathutil.CacheDir, indexDir = dir, filepath.Join(dir, "index")
+	t.Cleanup(func() { pathutil.CacheDir, indexDir = cacheDir, index })
+	return dir
+}
+
+// writeFile writes data to path and sets its modification time.
+func writeFile(t *testing.T, path, data string, modTime time.Time) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(data), 0o640); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(path, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+func TestHashMigrate(t *testing.T) {
+	now := time.Now().Truncate(time.Second)
+
+	testdata := []struct {
+		name     string
+		source   time.Time
+		legacy   time.Time
+		migrated bool
+	}{
+		{"cache newer than source", now.Add(-time.Hour), now, true},
+		{"cache as old as source", now, now, true},
+		{"source changed after cache", now, now.Add(-time.Hour), false},
+	}
+
+	for _, test := range testdata {
+		t.Run(test.name, func(t *testing.T) {
+			dir := useCacheDir(t)
+			src := filepath.Join(t.TempDir(), "image.png")
+			writeFile(t, src, "image", test.source)
+
+			legacy := filepath.Join(dir, pathHash(src)+".json")
+			writeFile(t, legacy, "{}", test.legacy)
+
+			key, err := Hash(src)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if exists(legacy) {
+				t.Errorf("legacy cache %s was left in place", legacy)
+			}
+			if got := exists(filepath.Join(dir, key+".json")); got != test.migrated {
+				t.Errorf("migrated = %v, want %v", got, test.migrated)
+			}
+		})
+	}
+}
+
+func TestHashIndex(t *testing.T) {
+	useCacheDir(t)
+	dir := t.TempDir()
+	now := time.Now().Truncate(time.Second)
+
+	src := filepath.Join(dir, "image.png")
+	writeFile(t, src, "image", now)
+	key, err := Hash(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	moved := filepath.Join(dir, "moved.png")
+	if err := os.Rename(src, moved); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := Hash(moved); err != nil || got != key {
+		t.Errorf("Hash(moved) = %q, %v, want %q", got, err, key)
+	}
+
+	writeFile(t, moved, "changed", now.Add(time.Second))
+	if got, err := Hash(moved); err != nil || got == key {
+		t.Errorf("Hash(changed) = %q, %v, want a new key", got, err)
+	}
+}
@@ -0,0 +1,203 @@
+package cache
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Nadim147c/material/v3/color"
+	"github.com/Nadim147c/material/v3/score"
+	"github.com/Nadim147c/rong/v5/internal/config/enums"
+	"github.com/Nadim147c/rong/v5/internal/material"
+	"github.com/Nadim147c/rong/v5/internal/pathutil"
+	"github.com/gabriel-vasile/mimetype"
+	"github.com/google/renameio/v2"
+)
+
+// Errors returned by Resolve.
+var (
+	ErrNotCached = errors.New("not cached")
+	ErrAmbiguous = errors.New("ambiguous cache key")
+)
+
+// Meta describes a cache entry and the source it was cached from.
+type Meta struct {
+	Key      string       `json:"key"`
+	Source   string       `json:"source"`
+	Mime     string       `json:"mime"`
+	Size     int64        `json:"size"`
+	ModTime  time.Time    `json:"mod_time"`
+	CachedAt time.Time    `json:"cached_at"`
+	Preview  string       `json:"preview,omitempty"`
+	Colors   []color.ARGB `json:"colors"`
+	// Imported entries were imported from a bundle. Their source is a file
+	// on the exporting machine, not a local file.
+	Imported bool `json:"imported,omitempty"`
+}
+
+func metaPath(key string) string {
+	return filepath.Join(pathutil.CacheDir, key+".meta.json")
+}
+
+// newMeta creates the metadata of an entry cached from the file at path.
+// source is the source recorded for the entry.
+func newMeta(path, source, key string, output material.Quantized) (Meta, error) {
+	meta := Meta{Key: key, Source: source, CachedAt: time.Now()}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return meta, err
+	}
+	meta.Size, meta.ModTime = info.Size(), info.ModTime()
+
+	mtype, err := mimetype.DetectFile(path)
+	if err != nil {
+		return meta, err
+	}
+	meta.Mime = mtype.String()
+
+	meta.Colors = score.Score(output.Celebi, score.WithFilter(), score.WithLimit(5))
+	return meta, nil
+}
+
+// saveMeta writes the metadata of the entry cached from the file at path.
+func saveMeta(path, source, key string, output material.Quantized) error {
+	meta, err := newMeta(path, source, key, output)
+	if err != nil {
+		return err
+	}
+	return writeMeta(meta)
+}
+
+func writeMeta(meta Meta) error {
+	data, err := json.Marshal(meta)
+	if err != nil {
+		return err
+	}
+	return renameio.WriteFile(metaPath(meta.Key), data, 0o640)
+}
+
+// readMeta reads the recorded metadata of the entry of key.
+func readMeta(key string) (Meta, error) {
+	var meta Meta
+	data, err := os.ReadFile(metaPath(key))
+	if err != nil {
+		return meta, err
+	}
+	err = json.Unmarshal(data, &meta)
+	return meta, err
+}
+
+// loadMeta returns the metadata of entry. Entries cached before metadata was
+// recorded get it from their first source and cached colors. That metadata is
+// only built for reading, it is written when the entry is generated again.
+func loadMeta(entry Entry) (Meta, error) {
+	meta, err := readMeta(entry.Key)
+	if err != nil {
+		if len(entry.Sources) == 0 {
+			return meta, fmt.Errorf("%w: no source for %s", ErrNotCached, entry.Key)
+		}
+
+		output, err := readEntry(entry)
+		if err != nil {
+			return meta, err
+		}
+
+		source := entry.Sources[0]
+		meta, err = newMeta(source, source, entry.Key, output)
+		if err != nil {
+			return meta, err
+		}
+		if info, err := os.Stat(filepath.Join(pathutil.CacheDir, entry.Key+".json")); err == nil {
+			meta.CachedAt = info.ModTime()
+		}
+	}
+
+	if !slices.Contains(entry.Sources, meta.Source) && len(entry.Sources) != 0 {
+		meta.Source = entry.Sources[0]
+	}
+
+	for _, file := range entry.Files {
+		ext := strings.TrimPrefix(filepath.Ext(file), ".")
+		if _, err := enums.ParsePreviewFormat(ext); err == nil {
+			meta.Preview = file
+			break
+		}
+	}
+
+	return meta, nil
+}
+
+// Index returns the metadata of every cache entry, most recently cached
+// first.
+func Index() ([]Meta, error) {
+	entries, err := Entries()
+	if err != nil {
+		return nil, err
+	}
+
+	index := make([]Meta, 0, len(entries))
+	for _, entry := range entries {
+		meta, err := loadMeta(entry)
+		if err != nil {
+			slog.Debug("Skipping cache entry", "key", entry.Key, "error", err)
+			continue
+		}
+		index = append(index, meta)
+	}
+
+	slices.SortFunc(index, func(a, b Meta) int { return b.CachedAt.Compare(a.CachedAt) })
+	return index, nil
+}
+
+// Show returns the metadata of the cache entry of a file path, cache key or
+// unique prefix of a cache key.
+func Show(arg string) (Meta, error) {
+	entry, err := resolve(arg)
+	if err != nil {
+		return Meta{}, err
+	}
+	return loadMeta(entry)
+}
+
+// resolve finds the cache entry of a file path, cache key or unique prefix of
+// a cache key.
+func resolve(arg string) (Entry, error) {
+	key := arg
+	if info, err := os.Stat(arg); err == nil && info.Mode().IsRegular() {
+		key, err = Hash(arg)
+		if err != nil {
+			return Entry{}, err
+		}
+	}
+
+	entries, err := Entries()
+	if err != nil {
+		return Entry{}, err
+	}
+
+	var found []Entry
+	for _, entry := range entries {
+		if entry.Key == key {
+			return entry, nil
+		}
+		if strings.HasPrefix(entry.Key, key) {
+			found = append(found, entry)
+		}
+	}
+
+	switch len(found) {
+	case 0:
+		return Entry{}, fmt.Errorf("%w: %s", ErrNotCached, arg)
+	case 1:
+		return found[0], nil
+	default:
+		return Entry{}, fmt.Errorf("%w: %s matches %d entries", ErrAmbiguous, arg, len(found))
+	}
+}
@@ -0,0 +1,51 @@
+package cache
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Nadim147c/rong/v5/internal/material"
+)
+
+func TestShowDoesNotWrite(t *testing.T) {
+	useCacheDir(t)
+	key := cacheSource(t, filepath.Join(t.TempDir(), "a.png"), "a")
+	if err := os.Remove(metaPath(key)); err != nil {
+		t.Fatal(err)
+	}
+
+	meta, err := Show(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if meta.Key != key || meta.Source == "" {
+		t.Errorf("Show(%q) = %+v", key, meta)
+	}
+	if exists(metaPath(key)) {
+		t.Error("Show wrote metadata of the entry")
+	}
+}
+
+func TestSaveStdinCache(t *testing.T) {
+	useCacheDir(t)
+	path := filepath.Join(t.TempDir(), "stdin")
+	writeFile(t, path, "image", time.Now())
+	key, err := HashContent(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := SaveStdinCache(path, key, material.Quantized{}); err != nil {
+		t.Fatal(err)
+	}
+
+	meta, err := readMeta(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if meta.Source != "-" {
+		t.Errorf("source = %q, want %q", meta.Source, "-")
+	}
+}
@@ -4,18 +4,79 @@ import (
 	"context"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
 
 	"github.com/Nadim147c/rong/v5/internal/config"
+	"github.com/Nadim147c/rong/v5/internal/config/enums"
 	"github.com/Nadim147c/rong/v5/internal/ffmpeg"
 	"github.com/Nadim147c/rong/v5/internal/pathutil"
 )
 
-// GetPreview returns the preview image.
-func GetPreview(src string, hash string) (string, error) {
-	format := config.PreviewFormat.Value()
-	path := filepath.Join(pathutil.CacheDir, hash+"."+format.String())
+// previewOptions returns the configured preview options of ctx.
+func previewOptions(ctx context.Context) (ffmpeg.Preview, error) {
+	columns, rows, err := ffmpeg.ParseGrid(config.PreviewGrid.Value(ctx))
+	if err != nil {
+		return ffmpeg.Preview{}, err
+	}
+	return ffmpeg.Preview{
+		Format:   config.PreviewFormat.Value(ctx),
+		Mode:     config.PreviewMode.Value(ctx),
+		Seek:     config.PreviewSeek.Value(ctx),
+		MaxSize:  config.PreviewSize.Value(ctx),
+		Quality:  config.PreviewQuality.Value(ctx),
+		Duration: config.PreviewDuration.Value(ctx).Seconds(),
+		Columns:  columns,
+		Rows:     rows,
+	}, nil
+}
+
+// previewKey returns a string identifying preview options that differ from
+// the defaults, so previews of the default options keep the plain content key.
+func previewKey(p ffmpeg.Preview) string {
+	var parts []string
+	if p.Mode != config.PreviewMode.Default() {
+		parts = append(parts, "mode="+p.Mode.String())
+	}
+	if p.Mode != enums.PreviewModeSheet && p.Seek != config.PreviewSeek.Default() {
+		parts = append(parts, "seek="+p.Seek)
+	}
+	if p.MaxSize != config.PreviewSize.Default() {
+		parts = append(parts, "size="+strconv.Itoa(p.MaxSize))
+	}
+	if p.Quality != config.PreviewQuality.Default() {
+		parts = append(parts, "quality="+strconv.FormatFloat(p.Quality, 'f', -1, 64))
+	}
+	if p.Mode == enums.PreviewModeAnimated && p.Duration != config.PreviewDuration.Default().Seconds() {
+		parts = append(parts, "duration="+strconv.FormatFloat(p.Duration, 'f', -1, 64))
+	}
+	if p.Mode == enums.PreviewModeSheet {
+		parts = append(parts, "grid="+strconv.Itoa(p.Columns)+"x"+strconv.Itoa(p.Rows))
+	}
+	return strings.Join(parts, ";")
+}
+
+// previewPath returns the path of the preview of the content of hash.
+func previewPath(hash string, p ffmpeg.Preview) string {
+	return filepath.Join(pathutil.CacheDir, Variant(hash, previewKey(p))+"."+p.Format.String())
+}
+
+// GetPreview returns the preview image of src, generating it when it is not
+// cached.
+func GetPreview(ctx context.Context, src string, hash string) (string, error) {
+	p, err := previewOptions(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	path := previewPath(hash, p)
 	if _, err := os.Stat(path); err == nil {
+		touch(path)
 		return path, nil
 	}
-	return path, ffmpeg.GeneratePreview(context.Background(), src, path)
+
+	if err := os.MkdirAll(pathutil.CacheDir, 0o750); err != nil {
+		return path, err
+	}
+	return path, ffmpeg.GeneratePreview(ctx, src, path, p)
 }
@@ -0,0 +1,286 @@
+package cache
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Nadim147c/rong/v5/internal/pathutil"
+)
+
+// Entry is a cache key and the files cached for it.
+type Entry struct {
+	Key      string    `json:"key"`
+	Files    []string  `json:"files"`
+	Sources  []string  `json:"sources"`
+	Size     int64     `json:"size"`
+	LastUsed time.Time `json:"last_used"`
+
+	// recorded are the index entries of the key, including paths that no
+	// longer match the cached content.
+	recorded []pathEntry
+}
+
+// Reason describes why an entry is pruned.
+type Reason string
+
+// Reasons to prune an entry.
+const (
+	ReasonOrphan Reason = "orphan"
+	ReasonUnused Reason = "unused"
+	ReasonSize   Reason = "size"
+)
+
+// Pruned is an entry removed by Prune.
+type Pruned struct {
+	Entry
+	Reason Reason `json:"reason"`
+}
+
+// PruneOptions configures Prune.
+type PruneOptions struct {
+	// MaxAge removes entries unused for longer than MaxAge. Zero disables it.
+	MaxAge time.Duration
+	// MaxSize removes least recently used entries until the cache fits in
+	// MaxSize bytes. Zero disables it.
+	MaxSize int64
+	// DryRun reports entries without removing anything.
+	DryRun bool
+}
+
+// touch marks a cache file as used now.
+func touch(path string) {
+	now := time.Now()
+	_ = os.Chtimes(path, now, now)
+}
+
+// pathEntries returns every entry of the path index.
+func pathEntries() ([]pathEntry, error) {
+	dir, err := os.ReadDir(indexDir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]pathEntry, 0, len(dir))
+	for _, file := range dir {
+		if file.IsDir() || filepath.Ext(file.Name()) != ".json" {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(indexDir, file.Name()))
+		if err != nil {
+			continue
+		}
+		var entry pathEntry
+		if json.Unmarshal(data, &entry) != nil || entry.Path == "" {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// valid reports whether the source of the entry still has the same content.
+func (e pathEntry) valid() bool {
+	info, err := os.Stat(e.Path)
+	return err == nil && info.Size() == e.Size && info.ModTime().Equal(e.ModTime)
+}
+
+// Entries returns all cache entries sorted by key. Sources only lists paths
+// that still match the cached content.
+func Entries() ([]Entry, error) {
+	dir, err := os.ReadDir(pathutil.CacheDir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	byKey := map[string]*Entry{}
+	for _, file := range dir {
+		name := file.Name()
+		key, _, ok := strings.Cut(name, ".")
+		if file.IsDir() || !ok || key == "" {
+			continue
+		}
+		info, err := file.Info()
+		if err != nil {
+			continue
+		}
+
+		entry, ok := byKey[key]
+		if !ok {
+			entry = &Entry{Key: key}
+			byKey[key] = entry
+		}
+		entry.Files = append(entry.Files, filepath.Join(pathutil.CacheDir, name))
+		entry.Size += info.Size()
+		if info.ModTime().After(entry.LastUsed) {
+			entry.LastUsed = info.ModTime()
+		}
+	}
+
+	index, err := pathEntries()
+	if err != nil {
+		return nil, err
+	}
+	for _, src := range index {
+		entry, ok := byKey[src.Key]
+		if !ok {
+			continue
+		}
+		entry.recorded = append(entry.recorded, src)
+		if src.valid() {
+			entry.Sources = append(entry.Sources, src.Path)
+		}
+	}
+
+	entries := make([]Entry, 0, len(byKey))
+	for _, entry := range byKey {
+		entries = append(entries, *entry)
+	}
+	slices.SortFunc(entries, func(a, b Entry) int { return strings.Compare(a.Key, b.Key) })
+	return entries, nil
+}
+
+// live reports whether entry has a local source or was imported from a bundle.
+// Only live entries are exported, searched and compared.
+func (e Entry) live() bool {
+	if len(e.Sources) != 0 {
+		return true
+	}
+	meta, err := readMeta(e.Key)
+	return err == nil && meta.Imported
+}
+
+// orphan reports whether every source entry was cached from is gone. Entries
+// without a recorded source, like imported entries, colors of standard input
+// and caches created before sources were recorded, are never orphans. Sources
+// renamed within their directory are found by content and indexed again
+// unless dryRun is set.
+func orphan(entry Entry, dryRun bool) bool {
+	if len(entry.Sources) != 0 {
+		return false
+	}
+
+	recorded := entry.recorded
+	meta, err := readMeta(entry.Key)
+	if err == nil && !meta.Imported && filepath.IsAbs(meta.Source) &&
+		!slices.ContainsFunc(recorded, func(e pathEntry) bool { return e.Path == meta.Source }) {
+		recorded = append(recorded, pathEntry{Path: meta.Source, Key: entry.Key, Size: meta.Size})
+	}
+	if len(recorded) == 0 {
+		return false
+	}
+
+	for _, src := range recorded {
+		if relocate(src, dryRun) {
+			return false
+		}
+	}
+	return true
+}
+
+// relocate looks for a file with the content of src in the directory of src
+// and adds the file to the index unless dryRun is set. It reports whether a
+// file is found.
+func relocate(src pathEntry, dryRun bool) bool {
+	dir := filepath.Dir(src.Path)
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		return false
+	}
+
+	for _, file := range files {
+		info, err := file.Info()
+		if err != nil || !info.Mode().IsRegular() || info.Size() != src.Size {
+			continue
+		}
+		path := filepath.Join(dir, file.Name())
+		key, err := contentHash(path)
+		if err != nil || key != src.Key {
+			continue
+		}
+		if !dryRun {
+			if err := savePathEntry(pathEntry{path, key, info.Size(), info.ModTime()}); err != nil {
+				slog.Warn("Failed to save cache index", "path", path, "error", err)
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// Prune removes cache entries whose sources no longer exist, entries unused
+// for longer than opts.MaxAge and least recently used entries beyond
+// opts.MaxSize. Index entries of missing or changed sources are removed too.
+func Prune(opts PruneOptions) ([]Pruned, error) {
+	entries, err := Entries()
+	if err != nil {
+		return nil, err
+	}
+
+	var pruned []Pruned
+	var kept []Entry
+	var size int64
+	for _, entry := range entries {
+		switch {
+		case orphan(entry, opts.DryRun):
+			pruned = append(pruned, Pruned{entry, ReasonOrphan})
+		case opts.MaxAge > 0 && time.Since(entry.LastUsed) > opts.MaxAge:
+			pruned = append(pruned, Pruned{entry, ReasonUnused})
+		default:
+			kept = append(kept, entry)
+			size += entry.Size
+		}
+	}
+
+	if opts.MaxSize > 0 && size > opts.MaxSize {
+		slices.SortFunc(kept, func(a, b Entry) int { return a.LastUsed.Compare(b.LastUsed) })
+		for _, entry := range kept {
+			if size <= opts.MaxSize {
+				break
+			}
+			pruned = append(pruned, Pruned{entry, ReasonSize})
+			size -= entry.Size
+		}
+	}
+
+	if opts.DryRun {
+		return pruned, nil
+	}
+
+	var errs []error
+	for _, entry := range pruned {
+		for _, file := range entry.Files {
+			if err := os.Remove(file); err != nil && !errors.Is(err, fs.ErrNotExist) {
+				errs = append(errs, err)
+			}
+		}
+	}
+
+	index, err := pathEntries()
+	if err != nil {
+		return pruned, errors.Join(append(errs, err)...)
+	}
+	for _, src := range index {
+		if src.valid() {
+			continue
+		}
+		err := os.Remove(filepath.Join(indexDir, pathHash(src.Path)+".json"))
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			errs = append(errs, err)
+		}
+	}
+
+	return pruned, errors.Join(errs...)
+}
@@ -0,0 +1,184 @@
+package cache
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/Nadim147c/rong/v5/internal/pathutil"
+)
+
+// colorsPath returns the path of the cached colors of key.
+func colorsPath(key string) string {
+	return filepath.Join(pathutil.CacheDir, key+".json")
+}
+
+// cacheSource writes a source file with data and caches it like SaveCache.
+func cacheSource(t *testing.T, path, data string) string {
+	t.Helper()
+	writeFile(t, path, data, time.Now().Add(-time.Hour))
+	key, err := Hash(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, colorsPath(key), "{}", time.Now())
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	meta := Meta{Key: key, Source: path, Size: info.Size(), CachedAt: time.Now()}
+	if err := writeMeta(meta); err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func TestPruneOrphans(t *testing.T) {
+	testdata := []struct {
+		name   string
+		setup  func(t *testing.T, dir string) string
+		orphan bool
+	}{
+		{"existing source", func(t *testing.T, dir string) string {
+			return cacheSource(t, filepath.Join(dir, "a.png"), "a")
+		}, false},
+		{"removed source", func(t *testing.T, dir string) string {
+			path := filepath.Join(dir, "a.png")
+			key := cacheSource(t, path, "a")
+			if err := os.Remove(path); err != nil {
+				t.Fatal(err)
+			}
+			return key
+		}, true},
+		{"changed source", func(t *testing.T, dir string) string {
+			path := filepath.Join(dir, "a.png")
+			key := cacheSource(t, path, "a")
+			writeFile(t, path, "changed", time.Now())
+			return key
+		}, true},
+		{"renamed source", func(t *testing.T, dir string) string {
+			path := filepath.Join(dir, "a.png")
+			key := cacheSource(t, path, "a")
+			if err := os.Rename(path, filepath.Join(dir, "b.png")); err != nil {
+				t.Fatal(err)
+			}
+			return key
+		}, false},
+		{"moved and hashed source", func(t *testing.T, dir string) string {
+			path := filepath.Join(dir, "a.png")
+			key := cacheSource(t, path, "a")
+			moved := filepath.Join(t.TempDir(), "a.png")
+			if err := os.Rename(path, moved); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := Hash(moved); err != nil {
+				t.Fatal(err)
+			}
+			return key
+		}, false},
+		{"legacy cache", func(t *testing.T, _ string) string {
+			key := pathHash("/wallpapers/a.png")
+			writeFile(t, colorsPath(key), "{}", time.Now())
+			return key
+		}, false},
+		{"imported entry", func(t *testing.T, _ string) string {
+			key := "0123456789abcdef0123456789abcdef"
+			writeFile(t, colorsPath(key), "{}", time.Now())
+			meta := Meta{Key: key, Source: "/home/other/a.png", Imported: true}
+			if err := writeMeta(meta); err != nil {
+				t.Fatal(err)
+			}
+			return key
+		}, false},
+	}
+
+	for _, test := range testdata {
+		t.Run(test.name, func(t *testing.T) {
+			useCacheDir(t)
+			key := test.setup(t, t.TempDir())
+
+			pruned, err := Prune(PruneOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got := slices.ContainsFunc(pruned, func(p Pruned) bool { return p.Key == key })
+			if got != test.orphan {
+				t.Errorf("pruned = %v, want %v", got, test.orphan)
+			}
+			if kept := exists(metaPath(key)) || exists(colorsPath(key)); kept == test.orphan {
+				t.Errorf("files kept = %v, want %v", kept, !test.orphan)
+			}
+		})
+	}
+}
+
+func TestPruneSelection(t *testing.T) {
+	now := time.Now()
+
+	// entries are cached with 10 bytes and last used the given time ago.
+	entries := map[string]time.Duration{
+		"aa": time.Minute,
+		"bb": time.Hour,
+		"cc": 48 * time.Hour,
+	}
+
+	testdata := []struct {
+		name string
+		opts PruneOptions
+		want map[string]Reason
+	}{
+		{"no limits", PruneOptions{}, map[string]Reason{}},
+		{"max age", PruneOptions{MaxAge: 24 * time.Hour}, map[string]Reason{"cc": ReasonUnused}},
+		{"max size", PruneOptions{MaxSize: 20}, map[string]Reason{"cc": ReasonSize}},
+		{"max size evicts least recently used", PruneOptions{MaxSize: 10}, map[string]Reason{
+			"bb": ReasonSize,
+			"cc": ReasonSize,
+		}},
+		{"max age before max size", PruneOptions{MaxAge: 24 * time.Hour, MaxSize: 10}, map[string]Reason{
+			"bb": ReasonSize,
+			"cc": ReasonUnused,
+		}},
+		{"dry run", PruneOptions{MaxSize: 10, DryRun: true}, map[string]Reason{
+			"bb": ReasonSize,
+			"cc": ReasonSize,
+		}},
+	}
+
+	for _, test := range testdata {
+		t.Run(test.name, func(t *testing.T) {
+			dir := useCacheDir(t)
+			for key, age := range entries {
+				writeFile(t, filepath.Join(dir, key+".json"), "0123456789", now.Add(-age))
+			}
+
+			pruned, err := Prune(test.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got := map[string]Reason{}
+			for _, p := range pruned {
+				got[p.Key] = p.Reason
+			}
+			if len(got) != len(test.want) {
+				t.Errorf("pruned %v, want %v", got, test.want)
+			}
+			for key, reason := range test.want {
+				if got[key] != reason {
+					t.Errorf("reason of %s = %q, want %q", key, got[key], reason)
+				}
+			}
+
+			for key := range entries {
+				_, pruned := got[key]
+				removed := !exists(filepath.Join(dir, key+".json"))
+				if removed != (pruned && !test.opts.DryRun) {
+					t.Errorf("%s removed = %v", key, removed)
+				}
+			}
+		})
+	}
+}
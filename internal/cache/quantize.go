@@ -0,0 +1,88 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+
+	"github.com/Nadim147c/rong/v5/internal/material"
+	"github.com/Nadim147c/rong/v5/internal/media"
+)
+
+// Quantize returns the quantized colors of m read from src and the content
+// hash of src. Colors are loaded from the cache when possible and saved to it
+// otherwise.
+func Quantize(
+	ctx context.Context,
+	src media.Source,
+	m media.Media,
+	opts media.Options,
+) (material.Quantized, string, error) {
+	hashFile := Hash
+	if src.Stdin {
+		hashFile = HashContent
+	}
+	hash, err := hashFile(src.Path)
+	if err != nil {
+		return material.Quantized{}, "", fmt.Errorf("failed to get xxh sum: %w", err)
+	}
+	key := Variant(hash, opts.Key(m))
+
+	quantized, err := LoadCache(key)
+	if err == nil {
+		return quantized, hash, nil
+	}
+	if !errors.Is(err, fs.ErrNotExist) {
+		slog.Error("Failed to load cache", "error", err)
+	}
+
+	quantized, err = decode(ctx, m, opts)
+	if err != nil {
+		return quantized, hash, err
+	}
+
+	saveCache := SaveCache
+	if src.Stdin {
+		saveCache = SaveStdinCache
+	}
+	if err := saveCache(src.Path, key, quantized); err != nil {
+		slog.Warn("Failed to save colors to cache", "error", err)
+	}
+	return quantized, hash, nil
+}
+
+// Peek returns the quantized colors of m read from src like Quantize, without
+// writing to the cache. The path of src is not indexed, and cached colors are
+// neither migrated nor marked as used. Colors that aren't cached are decoded
+// and not saved.
+func Peek(
+	ctx context.Context,
+	src media.Source,
+	m media.Media,
+	opts media.Options,
+) (material.Quantized, error) {
+	hash, err := HashContent(src.Path)
+	if err != nil {
+		return material.Quantized{}, fmt.Errorf("failed to get xxh sum: %w", err)
+	}
+
+	quantized, err := readCache(Variant(hash, opts.Key(m)))
+	if err == nil {
+		return quantized, nil
+	}
+	if !errors.Is(err, fs.ErrNotExist) {
+		slog.Error("Failed to load cache", "error", err)
+	}
+	return decode(ctx, m, opts)
+}
+
+// decode quantizes the pixels of m.
+func decode(ctx context.Context, m media.Media, opts media.Options) (material.Quantized, error) {
+	pixels, err := m.Pixels(ctx, opts)
+	if err != nil {
+		return material.Quantized{}, fmt.Errorf("failed to get pixels from media: %w", err)
+	}
+	return material.Quantize(ctx, pixels, opts.Quantize)
+}
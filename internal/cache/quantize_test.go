@@ -0,0 +1,120 @@
+package cache
+
+import (
+	"image"
+	stdcolor "image/color"
+	"image/png"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/Nadim147c/rong/v5/internal/config"
+	"github.com/Nadim147c/rong/v5/internal/media"
+)
+
+// writeImage writes a png image of a single color to path.
+func writeImage(t *testing.T, path string) {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, 4, 4))
+	for y := range 4 {
+		for x := range 4 {
+			img.Set(x, y, stdcolor.RGBA{R: 0xcc, G: 0x33, B: 0x33, A: 0xff})
+		}
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	if err := png.Encode(file, img); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// files returns the paths of the files below dir.
+func files(t *testing.T, dir string) []string {
+	t.Helper()
+	var paths []string
+	err := filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if err == nil && !d.IsDir() {
+			paths = append(paths, path)
+		}
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return paths
+}
+
+func TestPeek(t *testing.T) {
+	ctx := config.WithStore(t.Context(), config.New())
+	opts, err := media.GetOptions(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testdata := []struct {
+		name   string
+		cached bool
+	}{
+		{"uncached media", false},
+		{"cached media", true},
+	}
+
+	for _, test := range testdata {
+		t.Run(test.name, func(t *testing.T) {
+			dir := useCacheDir(t)
+			path := filepath.Join(t.TempDir(), "image.png")
+			writeImage(t, path)
+			src := media.Source{Path: path, Weight: 1}
+			m, err := media.Detect(ctx, path)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var want []string
+			if test.cached {
+				if _, _, err := Quantize(ctx, src, m, opts); err != nil {
+					t.Fatal(err)
+				}
+				want = files(t, dir)
+			}
+			used := time.Now().Add(-time.Hour).Truncate(time.Second)
+			for _, path := range want {
+				if err := os.Chtimes(path, used, used); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			quantized, err := Peek(ctx, src, m, opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			decoded, err := decode(ctx, m, opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !maps.Equal(quantized.Celebi, decoded.Celebi) || !slices.Equal(quantized.Wu, decoded.Wu) {
+				t.Errorf("Peek = %v, want %v", quantized, decoded)
+			}
+
+			got := files(t, dir)
+			if len(got) != len(want) {
+				t.Fatalf("cache files = %v, want %v", got, want)
+			}
+			for _, path := range got {
+				info, err := os.Stat(path)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !info.ModTime().Equal(used) {
+					t.Errorf("%s was modified", path)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,155 @@
+package cache
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/Nadim147c/material/v3/color"
+)
+
+// searchSigma is the OkLab distance at which a color counts ~37% as a match.
+const searchSigma = 0.08
+
+// ErrInvalidRange means a range could not be parsed.
+var ErrInvalidRange = errors.New("invalid range")
+
+// Range is an inclusive range of values. A range with Min greater than Max
+// wraps around, which is used for hues like 330-30.
+type Range struct {
+	Min, Max float64
+}
+
+// ParseRange parses a range in min-max format. Either side can be omitted to
+// use lo or hi instead.
+func ParseRange(s string, lo, hi float64) (Range, error) {
+	minStr, maxStr, ok := strings.Cut(s, "-")
+	if !ok {
+		return Range{}, fmt.Errorf("%w %q: want min-max", ErrInvalidRange, s)
+	}
+
+	r := Range{lo, hi}
+	var err error
+	if minStr = strings.TrimSpace(minStr); minStr != "" {
+		if r.Min, err = strconv.ParseFloat(minStr, 64); err != nil {
+			return r, fmt.Errorf("%w %q: %w", ErrInvalidRange, s, err)
+		}
+	}
+	if maxStr = strings.TrimSpace(maxStr); maxStr != "" {
+		if r.Max, err = strconv.ParseFloat(maxStr, 64); err != nil {
+			return r, fmt.Errorf("%w %q: %w", ErrInvalidRange, s, err)
+		}
+	}
+	return r, nil
+}
+
+// Contains reports whether v is in the range.
+func (r Range) Contains(v float64) bool {
+	if r.Min <= r.Max {
+		return r.Min <= v && v <= r.Max
+	}
+	return r.Min <= v || v <= r.Max
+}
+
+// SearchOptions configures Search.
+type SearchOptions struct {
+	// Color ranks entries by how strongly they feature it. Zero alpha ranks by
+	// the share of colors matching Hue and Chroma only.
+	Color color.ARGB
+	// Hue and Chroma only count colors inside these HCT ranges when set.
+	Hue, Chroma *Range
+	// Limit is the maximum number of results. Zero returns all.
+	Limit int
+}
+
+// Match is a cache entry found by Search.
+type Match struct {
+	Meta
+	Score float64 `json:"score"`
+}
+
+// Search ranks cached entries by population share of colors close to
+// opts.Color in OkLab, weighted by their distance.
+func Search(opts SearchOptions) ([]Match, error) {
+	entries, err := Entries()
+	if err != nil {
+		return nil, err
+	}
+
+	var target color.OkLab
+	if opts.Color.Alpha() != 0 {
+		target = opts.Color.ToOkLab()
+	}
+
+	type found struct {
+		entry Entry
+		score float64
+	}
+
+	var ranked []found
+	for _, entry := range entries {
+		if !entry.live() {
+			continue
+		}
+		output, err := readEntry(entry)
+		if err != nil {
+			continue
+		}
+
+		var total, score float64
+		for c, population := range output.Celebi {
+			total += float64(population)
+
+			if opts.Hue != nil || opts.Chroma != nil {
+				hct := c.ToHct()
+				if opts.Hue != nil && !opts.Hue.Contains(hct.Hue) {
+					continue
+				}
+				if opts.Chroma != nil && !opts.Chroma.Contains(hct.Chroma) {
+					continue
+				}
+			}
+
+			weight := 1.0
+			if opts.Color.Alpha() != 0 {
+				d := distance(c.ToOkLab(), target) / searchSigma
+				weight = math.Exp(-d * d)
+			}
+			score += float64(population) * weight
+		}
+		if total == 0 || score/total < 1e-4 {
+			continue
+		}
+
+		ranked = append(ranked, found{entry, score / total})
+	}
+
+	slices.SortStableFunc(ranked, func(a, b found) int { return cmp.Compare(b.score, a.score) })
+	if opts.Limit > 0 && len(ranked) > opts.Limit {
+		ranked = ranked[:opts.Limit]
+	}
+
+	matches := make([]Match, len(ranked))
+	for i, f := range ranked {
+		meta, err := loadMeta(f.entry)
+		if err != nil {
+			meta = Meta{Key: f.entry.Key}
+			if len(f.entry.Sources) != 0 {
+				meta.Source = f.entry.Sources[0]
+			}
+		}
+		matches[i] = Match{meta, f.score}
+	}
+
+	return matches, nil
+}
+
+// distance returns euclidean distance of two OkLab colors.
+func distance(a, b color.OkLab) float64 {
+	dL, dA, dB := a.L-b.L, a.A-b.A, a.B-b.B
+	return math.Sqrt(dL*dL + dA*dA + dB*dB)
+}
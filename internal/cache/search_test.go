@@ -0,0 +1,70 @@
+package cache
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseRange(t *testing.T) {
+	testdata := []struct {
+		name string
+		s    string
+		want Range
+		err  bool
+	}{
+		{"min and max", "10-20", Range{10, 20}, false},
+		{"spaces", " 10 - 20 ", Range{10, 20}, false},
+		{"fractions", "0.5-1.5", Range{0.5, 1.5}, false},
+		{"wrapping", "330-30", Range{330, 30}, false},
+		{"no min", "-50", Range{0, 50}, false},
+		{"no max", "50-", Range{50, 360}, false},
+		{"no bounds", "-", Range{0, 360}, false},
+		{"no separator", "50", Range{}, true},
+		{"invalid min", "a-20", Range{}, true},
+		{"invalid max", "10-b", Range{}, true},
+	}
+
+	for _, test := range testdata {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := ParseRange(test.s, 0, 360)
+			if test.err {
+				if !errors.Is(err, ErrInvalidRange) {
+					t.Errorf("ParseRange(%q) error = %v, want %v", test.s, err, ErrInvalidRange)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != test.want {
+				t.Errorf("ParseRange(%q) = %v, want %v", test.s, got, test.want)
+			}
+		})
+	}
+}
+
+func TestRangeContains(t *testing.T) {
+	testdata := []struct {
+		name string
+		r    Range
+		v    float64
+		want bool
+	}{
+		{"inside", Range{10, 20}, 15, true},
+		{"min", Range{10, 20}, 10, true},
+		{"max", Range{10, 20}, 20, true},
+		{"below", Range{10, 20}, 5, false},
+		{"above", Range{10, 20}, 25, false},
+		{"wrapping above min", Range{330, 30}, 350, true},
+		{"wrapping below max", Range{330, 30}, 10, true},
+		{"wrapping outside", Range{330, 30}, 180, false},
+	}
+
+	for _, test := range testdata {
+		t.Run(test.name, func(t *testing.T) {
+			if got := test.r.Contains(test.v); got != test.want {
+				t.Errorf("%v.Contains(%v) = %v, want %v", test.r, test.v, got, test.want)
+			}
+		})
+	}
+}
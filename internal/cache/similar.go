@@ -0,0 +1,241 @@
+package cache
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+
+	"github.com/Nadim147c/material/v3/color"
+	"github.com/Nadim147c/rong/v5/internal/material"
+	"github.com/Nadim147c/rong/v5/internal/media"
+)
+
+const (
+	// paletteSize is the number of most populated colors compared.
+	paletteSize = 32
+	// sinkhornEpsilon is the entropic regularization of the transport.
+	sinkhornEpsilon = 0.01
+	// sinkhornIterations is the maximum number of scaling iterations. Mass
+	// moved far compared to sinkhornEpsilon needs hundreds of them.
+	sinkhornIterations = 1000
+	// sinkhornTolerance is the error of the transported weights at which
+	// the iterations stop.
+	sinkhornTolerance = 1e-6
+)
+
+// Neighbor is a cache entry found by Similar.
+type Neighbor struct {
+	Meta
+	Distance float64 `json:"distance"`
+}
+
+// palette is a population weighted set of OkLab colors.
+type palette struct {
+	colors  []color.OkLab
+	weights []float64
+}
+
+// newPalette returns the most populated colors of output with weights that
+// sum to one.
+func newPalette(output material.Quantized) palette {
+	type pop struct {
+		color      color.ARGB
+		population int
+	}
+
+	pops := make([]pop, 0, len(output.Celebi))
+	for c, p := range output.Celebi {
+		pops = append(pops, pop{c, p})
+	}
+	slices.SortFunc(pops, func(a, b pop) int {
+		return cmp.Or(cmp.Compare(b.population, a.population), cmp.Compare(a.color, b.color))
+	})
+	pops = pops[:min(len(pops), paletteSize)]
+
+	var total float64
+	for _, p := range pops {
+		total += float64(p.population)
+	}
+
+	var pal palette
+	for _, p := range pops {
+		pal.colors = append(pal.colors, p.color.ToOkLab())
+		pal.weights = append(pal.weights, float64(p.population)/total)
+	}
+	return pal
+}
+
+// emd approximates the earth mover's distance between two palettes in OkLab
+// with Sinkhorn iterations.
+func emd(a, b palette) float64 {
+	n, m := len(a.weights), len(b.weights)
+	if n == 0 || m == 0 {
+		return math.Inf(1)
+	}
+
+	cost := make([]float64, n*m)
+	kernel := make([]float64, n*m)
+	for i := range n {
+		for j := range m {
+			c := distance(a.colors[i], b.colors[j])
+			cost[i*m+j] = c
+			kernel[i*m+j] = math.Exp(-c / sinkhornEpsilon)
+		}
+	}
+
+	u, v := make([]float64, n), make([]float64, m)
+	for j := range v {
+		v[j] = 1
+	}
+
+	for k := range sinkhornIterations {
+		// The weights of b are matched by the previous iteration, so the
+		// error is how far the weights moved from a are off.
+		var diff float64
+		for i := range n {
+			var sum float64
+			for j := range m {
+				sum += kernel[i*m+j] * v[j]
+			}
+			diff += math.Abs(u[i]*sum - a.weights[i])
+			u[i] = a.weights[i] / max(sum, math.SmallestNonzeroFloat64)
+		}
+		if k > 0 && diff < sinkhornTolerance {
+			break
+		}
+		for j := range m {
+			var sum float64
+			for i := range n {
+				sum += kernel[i*m+j] * u[i]
+			}
+			v[j] = b.weights[j] / max(sum, math.SmallestNonzeroFloat64)
+		}
+	}
+
+	var total float64
+	for i := range n {
+		for j := range m {
+			total += u[i] * kernel[i*m+j] * v[j] * cost[i*m+j]
+		}
+	}
+	return total
+}
+
+// Similar returns up to limit cache entries with palettes closest to the entry
+// of a file path or cache key. Media that isn't cached yet is quantized first.
+// Entries are compared by workers in parallel.
+func Similar(ctx context.Context, arg string, limit, workers int) ([]Neighbor, error) {
+	key, output, err := target(ctx, arg)
+	if err != nil {
+		return nil, err
+	}
+	want := newPalette(output)
+
+	entries, err := Entries()
+	if err != nil {
+		return nil, err
+	}
+
+	type result struct {
+		entry    Entry
+		distance float64
+	}
+
+	jobs := make(chan Entry)
+	results := make(chan result)
+
+	var wg sync.WaitGroup
+	for range max(workers, 1) {
+		wg.Go(func() {
+			for entry := range jobs {
+				output, err := readEntry(entry)
+				if err != nil {
+					continue
+				}
+				results <- result{entry, emd(want, newPalette(output))}
+			}
+		})
+	}
+
+	go func() {
+		defer close(jobs)
+		for _, entry := range entries {
+			if entry.Key == key || !entry.live() {
+				continue
+			}
+			select {
+			case jobs <- entry:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	var ranked []result
+	for r := range results {
+		if !math.IsInf(r.distance, 1) && !math.IsNaN(r.distance) {
+			ranked = append(ranked, r)
+		}
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	slices.SortStableFunc(ranked, func(a, b result) int { return cmp.Compare(a.distance, b.distance) })
+	if limit > 0 && len(ranked) > limit {
+		ranked = ranked[:limit]
+	}
+
+	neighbors := make([]Neighbor, len(ranked))
+	for i, r := range ranked {
+		meta, err := loadMeta(r.entry)
+		if err != nil {
+			meta = Meta{Key: r.entry.Key}
+			if len(r.entry.Sources) != 0 {
+				meta.Source = r.entry.Sources[0]
+			}
+		}
+		neighbors[i] = Neighbor{meta, r.distance}
+	}
+	return neighbors, nil
+}
+
+// target returns the key and the quantized colors of the cache entry of arg.
+// Files that aren't cached are quantized and cached.
+func target(ctx context.Context, arg string) (string, material.Quantized, error) {
+	entry, err := resolve(arg)
+	if err == nil {
+		output, err := readEntry(entry)
+		return entry.Key, output, err
+	}
+
+	info, statErr := os.Stat(arg)
+	if !errors.Is(err, ErrNotCached) || statErr != nil || !info.Mode().IsRegular() {
+		return "", material.Quantized{}, err
+	}
+
+	path, err := filepath.Abs(arg)
+	if err != nil {
+		return "", material.Quantized{}, err
+	}
+	m, err := media.Detect(ctx, path)
+	if err != nil {
+		return "", material.Quantized{}, err
+	}
+	opts, err := media.GetOptions(ctx)
+	if err != nil {
+		return "", material.Quantized{}, err
+	}
+	output, hash, err := Quantize(ctx, media.Source{Path: path, Weight: 1}, m, opts)
+	return hash, output, err
+}
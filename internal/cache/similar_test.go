@@ -0,0 +1,141 @@
+package cache
+
+import (
+	"errors"
+	"image"
+	"image/png"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Nadim147c/material/v3/color"
+	"github.com/Nadim147c/rong/v5/internal/config"
+)
+
+func TestEMD(t *testing.T) {
+	black := color.OkLab{L: 0}
+	gray := color.OkLab{L: 0.5}
+	white := color.OkLab{L: 1}
+	red := color.OkLab{L: 0.6, A: 0.2, B: 0.1}
+
+	testdata := []struct {
+		name string
+		a, b palette
+		want float64
+	}{
+		{
+			"identical",
+			palette{[]color.OkLab{black, white}, []float64{0.5, 0.5}},
+			palette{[]color.OkLab{black, white}, []float64{0.5, 0.5}},
+			0,
+		},
+		{
+			"reordered",
+			palette{[]color.OkLab{black, white}, []float64{0.3, 0.7}},
+			palette{[]color.OkLab{white, black}, []float64{0.7, 0.3}},
+			0,
+		},
+		{
+			"single colors",
+			palette{[]color.OkLab{black}, []float64{1}},
+			palette{[]color.OkLab{gray}, []float64{1}},
+			0.5,
+		},
+		{
+			"split weight",
+			palette{[]color.OkLab{black}, []float64{1}},
+			palette{[]color.OkLab{black, gray}, []float64{0.5, 0.5}},
+			0.25,
+		},
+		{
+			"moved share",
+			palette{[]color.OkLab{black, white}, []float64{0.8, 0.2}},
+			palette{[]color.OkLab{black, white}, []float64{0.6, 0.4}},
+			0.2,
+		},
+		{
+			"different sizes",
+			palette{[]color.OkLab{red}, []float64{1}},
+			palette{[]color.OkLab{red, red, red}, []float64{0.2, 0.3, 0.5}},
+			0,
+		},
+		{
+			"empty",
+			palette{},
+			palette{[]color.OkLab{black}, []float64{1}},
+			math.Inf(1),
+		},
+	}
+
+	for _, test := range testdata {
+		t.Run(test.name, func(t *testing.T) {
+			got := emd(test.a, test.b)
+			if math.IsInf(test.want, 1) {
+				if !math.IsInf(got, 1) {
+					t.Errorf("emd = %v, want +Inf", got)
+				}
+				return
+			}
+			if math.Abs(got-test.want) > 1e-3 {
+				t.Errorf("emd = %v, want %v", got, test.want)
+			}
+			if back := emd(test.b, test.a); math.Abs(back-got) > 1e-3 {
+				t.Errorf("emd is not symmetric: %v and %v", got, back)
+			}
+		})
+	}
+}
+
+func TestEMDOrder(t *testing.T) {
+	target := palette{[]color.OkLab{{L: 0.2}, {L: 0.8}}, []float64{0.5, 0.5}}
+	near := palette{[]color.OkLab{{L: 0.25}, {L: 0.75}}, []float64{0.5, 0.5}}
+	far := palette{[]color.OkLab{{L: 0.5, A: 0.1}}, []float64{1}}
+
+	if a, b := emd(target, near), emd(target, far); a >= b {
+		t.Errorf("near palette is %v away, far palette %v", a, b)
+	}
+}
+
+func TestSimilarTarget(t *testing.T) {
+	ctx := config.WithStore(t.Context(), config.New())
+
+	testdata := []struct {
+		name   string
+		arg    func(t *testing.T) string
+		cached bool
+		err    error
+	}{
+		{"uncached file", func(t *testing.T) string {
+			path := filepath.Join(t.TempDir(), "new.png")
+			file, err := os.Create(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer file.Close()
+			if err := png.Encode(file, image.NewGray(image.Rect(0, 0, 4, 4))); err != nil {
+				t.Fatal(err)
+			}
+			return path
+		}, true, nil},
+		{"unknown key", func(*testing.T) string { return "0123456789abcdef" }, false, ErrNotCached},
+	}
+
+	for _, test := range testdata {
+		t.Run(test.name, func(t *testing.T) {
+			useCacheDir(t)
+			arg := test.arg(t)
+
+			_, err := Similar(ctx, arg, 10, 2)
+			if !errors.Is(err, test.err) {
+				t.Fatalf("Similar error = %v, want %v", err, test.err)
+			}
+			if !test.cached {
+				return
+			}
+			if _, err := Show(arg); err != nil {
+				t.Errorf("target was not cached: %v", err)
+			}
+		})
+	}
+}
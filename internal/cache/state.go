@@ -2,6 +2,7 @@ package cache
 
 import (
 	"encoding/json"
+	"errors"
 	"os"
 	"path/filepath"
 
@@ -9,18 +10,32 @@ import (
 	"github.com/Nadim147c/rong/v5/internal/pathutil"
 )
 
-// State is the current generation state.
+// State is the current generation state. Path and Hash are of the first
+// source.
 type State struct {
 	Path      string             `json:"filename"`
 	Hash      string             `json:"hash"`
+	Sources   []Source           `json:"sources,omitempty"`
 	Quantized material.Quantized `json:"quantized"`
 }
 
-// SaveState saves state to state dir.
-func SaveState(source, hash string, output material.Quantized) error {
+// Source is a source of colors of the state.
+type Source struct {
+	Path   string  `json:"filename"`
+	Hash   string  `json:"hash"`
+	Weight float64 `json:"weight"`
+}
+
+// SaveState saves state to state dir. output is the quantized colors of all
+// sources merged.
+func SaveState(sources []Source, output material.Quantized) error {
+	if len(sources) == 0 {
+		return errors.New("no source to save")
+	}
+
 	path := filepath.Join(pathutil.StateDir, "state.json")
 
-	if err := os.MkdirAll(pathutil.CacheDir, 0o750); err != nil {
+	if err := os.MkdirAll(pathutil.StateDir, 0o750); err != nil {
 		return err
 	}
 
@@ -30,7 +45,7 @@ func SaveState(source, hash string, output material.Quantized) error {
 	}
 	defer file.Close()
 
-	state := State{source, hash, output}
+	state := State{sources[0].Path, sources[0].Hash, sources, output}
 
 	return json.NewEncoder(file).Encode(state)
 }
@@ -22,8 +22,12 @@ var (
 	JSON       = newBoolOption("j", "json", false, "Output generated colors as JSON")
 	SimpleJSON = newBoolOption("s", "simple-json", false, "Output colors as simple key-value JSON")
 	Quiet      = newBoolOption("q", "quiet", false, "Disable all logs")
+	Daemon     = newBoolOption("", "daemon", true, "Forward generation to a running rong daemon")
 
 	SourceColor    = newColorOption("P", "source-color", "#00000000", "Source color for color generator")
+	SourceRank     = newIntOption("", "source-rank", 1, "Rank of the scored color used as source color")
+	Pick           = newBoolOption("", "pick", false, "Pick the source color from scored colors interactively")
+	Compact        = newBoolOption("", "compact", false, "Only show the main colors in terminal previews")
 	MergeThreshold = newFloatOption("m", "merge-threshold", 2, "Minimun distance to merge similar colors")
 
 	Verbose = newCountOption("v", "verbose", "Increase log verbosity level")
@@ -35,11 +39,67 @@ var (
 		"p", "preview-format", enums.PreviewFormatJpg, "Output format for preview image",
 		enums.PreviewFormatNames(), enums.ParsePreviewFormat,
 	)
+	PreviewMode = newEnumOption(
+		"", "preview.mode", enums.PreviewModeFrame, "Kind of preview generated for videos",
+		enums.PreviewModeNames(), enums.ParsePreviewMode,
+	)
+	PreviewSeek     = newStringOption("", "preview.seek", "10%", "Time of videos to preview as a percentage or timestamp")
+	PreviewSize     = newIntOption("", "preview.size", 0, "Maximum width and height of previews (0 keeps the size)")
+	PreviewQuality  = newRangeFloatOption("", "preview.quality", 85, 100, 1, "Quality of lossy previews")
+	PreviewDuration = newDurationOption("", "preview.duration", 3*time.Second, "Length of animated previews")
+	PreviewGrid     = newStringOption("", "preview.grid", "3x3", "Columns and rows of frames in contact sheet previews")
+
+	CacheProgress = newEnumOption(
+		"", "progress", enums.ProgressAuto, "Progress output of the cache command",
+		enums.ProgressNames(), enums.ParseProgress,
+	)
 
 	FFmpegFrames   = newIntOption("", "frames", 5, "Number of frames to process with ffmpeg")
 	FFmpegDuration = newDurationOption("", "duration", 5*time.Second, "Maximum ffmpeg processing duration")
 	Workers        = newIntOption("", "workers", runtime.GOMAXPROCS(runtime.NumCPU()), "Number of worker threads to use")
 
+	Sampling = newEnumOption(
+		"", "sample", enums.SamplingWindow, "Strategy to pick frames of videos",
+		enums.SamplingNames(), enums.ParseSampling,
+	)
+	FFmpegOffset   = newDurationOption("", "offset", 0, "Time of videos to start sampling frames from")
+	FrameSize      = newIntOption("", "frame-size", 1024, "Maximum width and height of frames decoded with ffmpeg (0 keeps the size)")
+	SceneThreshold = newRangeFloatOption("", "scene-threshold", 0.3, 1, 0, "Minimum change between frames to start a new scene")
+
+	SaveSource = newBoolOption("", "save-source", false, "Keep media read from stdin in the state directory")
+
+	QuantizeMaxPixels = newIntOption("", "quantize.max-pixels", 1<<20, "Maximum number of pixels to quantize (0 for unlimited)")
+	QuantizeMethod    = newEnumOption(
+		"", "quantize.method", enums.QuantizerCelebi, "Algorithm to quantize pixels with",
+		enums.QuantizerNames(), enums.ParseQuantizer,
+	)
+	QuantizeMaxColors  = newIntOption("", "quantize.max-colors", 100, "Maximum number of quantized colors")
+	QuantizeIterations = newIntOption("", "quantize.iterations", 10, "Number of refinement iterations of celebi and kmeans")
+	QuantizeMinTone    = newRangeFloatOption("", "quantize.min-tone", 0, 100, 0, "Ignore pixels darker than this tone")
+	QuantizeMaxTone    = newRangeFloatOption("", "quantize.max-tone", 100, 100, 0, "Ignore pixels lighter than this tone")
+	QuantizeMinChroma  = newFloatOption("", "quantize.min-chroma", 0, "Ignore pixels less colorful than this chroma")
+
+	Crop         = newStringOption("", "crop", "", "Region of frames to use as x,y,w,h in pixels or percentages, or a centered percentage")
+	TrimBorders  = newBoolOption("", "trim-borders", false, "Ignore solid borders like letterboxes of frames")
+	CenterWeight = newIntOption("", "center-weight", 1, "Number of times pixels in the center of frames count")
+
+	Decoders      = newDecodersOption("", "decoders", "External commands decoding media types matching a mimetype pattern")
+	DecodeTimeout = newDurationOption("", "decode-timeout", 30*time.Second, "Maximum run time of external decoders")
+
+	Exclude        = newStringsOption("", "exclude", nil, "Glob patterns of files and directories to skip while scanning")
+	FollowSymlinks = newBoolOption("", "follow-symlinks", false, "Follow symlinked directories while scanning")
+	MaxDepth       = newIntOption("", "max-depth", 0, "Maximum directory depth to scan (0 for unlimited)")
+	Hidden         = newBoolOption("", "hidden", false, "Scan hidden files and directories")
+
+	CacheMaxAge    = newDurationOption("", "cache.max-age", 0, "Remove cache entries unused for this long (0 disables)")
+	CacheMaxSize   = newSizeOption("", "cache.max-size", 0, "Remove least recently used cache entries beyond this size (0 disables)")
+	CacheAutoPrune = newBoolOption("", "cache.auto-prune", false, "Prune the cache after caching files")
+
+	RotateHistory      = newIntOption("", "rotate.history", 10, "Number of recently used wallpapers random avoids")
+	RotatePreferCached = newBoolOption("", "rotate.prefer-cached", false, "Prefer wallpapers that are already cached")
+
+	WatchDebounce = newDurationOption("", "watch.debounce", 250*time.Millisecond, "Delay to wait for more changes before regenerating")
+
 	MaterialVersion = newEnumOption(
 		"", "material.version", dynamic.Version2025, "Material Design specification version",
 		dynamic.VersionNames(), dynamic.ParseVersion,
@@ -7,5 +7,30 @@ type Base16Method uint
 
 // PreviewFormat is a image format used for generating colors.
 //
-// ENUM(jpg, jpeg, png, webm).
+// ENUM(jpg, jpeg, png, webm, webp, gif).
 type PreviewFormat uint
+
+// PreviewMode is the kind of preview generated for videos.
+//
+// ENUM(frame, animated, sheet).
+type PreviewMode uint
+
+// Progress is the progress output of the cache command.
+//
+// ENUM(auto, tui, plain, json).
+type Progress uint
+
+// Sampling is the strategy used to pick frames of videos.
+//
+// ENUM(window, uniform, scene, keyframes).
+type Sampling uint
+
+// DecoderFormat is the image format external decoders write to stdout.
+//
+// ENUM(ppm, rgb24).
+type DecoderFormat uint
+
+// Quantizer is the algorithm used to quantize pixels.
+//
+// ENUM(celebi, wu, kmeans).
+type Quantizer uint
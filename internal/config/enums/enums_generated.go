@@ -98,6 +98,94 @@ func (x *Base16Method) AppendText(b []byte) ([]byte, error) {
 	return append(b, x.String()...), nil
 }
 
+const (
+	// DecoderFormatPpm is a DecoderFormat of type Ppm.
+	DecoderFormatPpm DecoderFormat = 0
+	// DecoderFormatRgb24 is a DecoderFormat of type Rgb24.
+	DecoderFormatRgb24 DecoderFormat = 1
+)
+
+var ErrInvalidDecoderFormat = fmt.Errorf("not a valid DecoderFormat, try [%s]", strings.Join(_DecoderFormatNames, ", "))
+
+const _DecoderFormatName = "ppmrgb24"
+
+var _DecoderFormatNames = []string{
+	_DecoderFormatName[0:3],
+	_DecoderFormatName[3:8],
+}
+
+// DecoderFormatNames returns a list of possible string values of DecoderFormat.
+func DecoderFormatNames() []string {
+	tmp := make([]string, len(_DecoderFormatNames))
+	copy(tmp, _DecoderFormatNames)
+	return tmp
+}
+
+// DecoderFormatValues returns a list of the values for DecoderFormat
+func DecoderFormatValues() []DecoderFormat {
+	return []DecoderFormat{
+		DecoderFormatPpm,
+		DecoderFormatRgb24,
+	}
+}
+
+var _DecoderFormatMap = map[DecoderFormat]string{
+	DecoderFormatPpm:   _DecoderFormatName[0:3],
+	DecoderFormatRgb24: _DecoderFormatName[3:8],
+}
+
+// String implements the Stringer interface.
+func (x DecoderFormat) String() string {
+	if str, ok := _DecoderFormatMap[x]; ok {
+		return str
+	}
+	return fmt.Sprintf("DecoderFormat(%d)", x)
+}
+
+// IsValid provides a quick way to determine if the typed value is
+// part of the allowed enumerated values
+func (x DecoderFormat) IsValid() bool {
+	_, ok := _DecoderFormatMap[x]
+	return ok
+}
+
+var _DecoderFormatValue = map[string]DecoderFormat{
+	_DecoderFormatName[0:3]: DecoderFormatPpm,
+	_DecoderFormatName[3:8]: DecoderFormatRgb24,
+}
+
+// ParseDecoderFormat attempts to convert a string to a DecoderFormat.
+func ParseDecoderFormat(name string) (DecoderFormat, error) {
+	if x, ok := _DecoderFormatValue[name]; ok {
+		return x, nil
+	}
+	return DecoderFormat(0), fmt.Errorf("%s is %w", name, ErrInvalidDecoderFormat)
+}
+
+// MarshalText implements the text marshaller method.
+func (x DecoderFormat) MarshalText() ([]byte, error) {
+	return []byte(x.String()), nil
+}
+
+// UnmarshalText implements the text unmarshaller method.
+func (x *DecoderFormat) UnmarshalText(text []byte) error {
+	name := string(text)
+	tmp, err := ParseDecoderFormat(name)
+	if err != nil {
+		return err
+	}
+	*x = tmp
+	return nil
+}
+
+// AppendText appends the textual representation of itself to the end of b
+// (allocating a larger slice if necessary) and returns the updated slice.
+//
+// Implementations must not retain b, nor mutate any bytes within b[:len(b)].
+func (x *DecoderFormat) AppendText(b []byte) ([]byte, error) {
+	return append(b, x.String()...), nil
+}
+
 const (
 	// PreviewFormatJpg is a PreviewFormat of type Jpg.
 	PreviewFormatJpg PreviewFormat = 0
@@ -107,17 +195,23 @@ const (
 	PreviewFormatPng PreviewFormat = 2
 	// PreviewFormatWebm is a PreviewFormat of type Webm.
 	PreviewFormatWebm PreviewFormat = 3
+	// PreviewFormatWebp is a PreviewFormat of type Webp.
+	PreviewFormatWebp PreviewFormat = 4
+	// PreviewFormatGif is a PreviewFormat of type Gif.
+	PreviewFormatGif PreviewFormat = 5
 )
 
 var ErrInvalidPreviewFormat = fmt.Errorf("not a valid PreviewFormat, try [%s]", strings.Join(_PreviewFormatNames, ", "))
 
-const _PreviewFormatName = "jpgjpegpngwebm"
+const _PreviewFormatName = "jpgjpegpngwebmwebpgif"
 
 var _PreviewFormatNames = []string{
 	_PreviewFormatName[0:3],
 	_PreviewFormatName[3:7],
 	_PreviewFormatName[7:10],
 	_PreviewFormatName[10:14],
+	_PreviewFormatName[14:18],
+	_PreviewFormatName[18:21],
 }
 
 // PreviewFormatNames returns a list of possible string values of PreviewFormat.
@@ -134,6 +228,8 @@ func PreviewFormatValues() []PreviewFormat {
 		PreviewFormatJpeg,
 		PreviewFormatPng,
 		PreviewFormatWebm,
+		PreviewFormatWebp,
+		PreviewFormatGif,
 	}
 }
 
@@ -142,6 +238,8 @@ var _PreviewFormatMap = map[PreviewFormat]string{
 	PreviewFormatJpeg: _PreviewFormatName[3:7],
 	PreviewFormatPng:  _PreviewFormatName[7:10],
 	PreviewFormatWebm: _PreviewFormatName[10:14],
+	PreviewFormatWebp: _PreviewFormatName[14:18],
+	PreviewFormatGif:  _PreviewFormatName[18:21],
 }
 
 // String implements the Stringer interface.
@@ -164,6 +262,8 @@ var _PreviewFormatValue = map[string]PreviewFormat{
 	_PreviewFormatName[3:7]:   PreviewFormatJpeg,
 	_PreviewFormatName[7:10]:  PreviewFormatPng,
 	_PreviewFormatName[10:14]: PreviewFormatWebm,
+	_PreviewFormatName[14:18]: PreviewFormatWebp,
+	_PreviewFormatName[18:21]: PreviewFormatGif,
 }
 
 // ParsePreviewFormat attempts to convert a string to a PreviewFormat.
@@ -197,3 +297,391 @@ func (x *PreviewFormat) UnmarshalText(text []byte) error {
 func (x *PreviewFormat) AppendText(b []byte) ([]byte, error) {
 	return append(b, x.String()...), nil
 }
+
+const (
+	// PreviewModeFrame is a PreviewMode of type Frame.
+	PreviewModeFrame PreviewMode = 0
+	// PreviewModeAnimated is a PreviewMode of type Animated.
+	PreviewModeAnimated PreviewMode = 1
+	// PreviewModeSheet is a PreviewMode of type Sheet.
+	PreviewModeSheet PreviewMode = 2
+)
+
+var ErrInvalidPreviewMode = fmt.Errorf("not a valid PreviewMode, try [%s]", strings.Join(_PreviewModeNames, ", "))
+
+const _PreviewModeName = "frameanimatedsheet"
+
+var _PreviewModeNames = []string{
+	_PreviewModeName[0:5],
+	_PreviewModeName[5:13],
+	_PreviewModeName[13:18],
+}
+
+// PreviewModeNames returns a list of possible string values of PreviewMode.
+func PreviewModeNames() []string {
+	tmp := make([]string, len(_PreviewModeNames))
+	copy(tmp, _PreviewModeNames)
+	return tmp
+}
+
+// PreviewModeValues returns a list of the values for PreviewMode
+func PreviewModeValues() []PreviewMode {
+	return []PreviewMode{
+		PreviewModeFrame,
+		PreviewModeAnimated,
+		PreviewModeSheet,
+	}
+}
+
+var _PreviewModeMap = map[PreviewMode]string{
+	PreviewModeFrame:    _PreviewModeName[0:5],
+	PreviewModeAnimated: _PreviewModeName[5:13],
+	PreviewModeSheet:    _PreviewModeName[13:18],
+}
+
+// String implements the Stringer interface.
+func (x PreviewMode) String() string {
+	if str, ok := _PreviewModeMap[x]; ok {
+		return str
+	}
+	return fmt.Sprintf("PreviewMode(%d)", x)
+}
+
+// IsValid provides a quick way to determine if the typed value is
+// part of the allowed enumerated values
+func (x PreviewMode) IsValid() bool {
+	_, ok := _PreviewModeMap[x]
+	return ok
+}
+
+var _PreviewModeValue = map[string]PreviewMode{
+	_PreviewModeName[0:5]:   PreviewModeFrame,
+	_PreviewModeName[5:13]:  PreviewModeAnimated,
+	_PreviewModeName[13:18]: PreviewModeSheet,
+}
+
+// ParsePreviewMode attempts to convert a string to a PreviewMode.
+func ParsePreviewMode(name string) (PreviewMode, error) {
+	if x, ok := _PreviewModeValue[name]; ok {
+		return x, nil
+	}
+	return PreviewMode(0), fmt.Errorf("%s is %w", name, ErrInvalidPreviewMode)
+}
+
+// MarshalText implements the text marshaller method.
+func (x PreviewMode) MarshalText() ([]byte, error) {
+	return []byte(x.String()), nil
+}
+
+// UnmarshalText implements the text unmarshaller method.
+func (x *PreviewMode) UnmarshalText(text []byte) error {
+	name := string(text)
+	tmp, err := ParsePreviewMode(name)
+	if err != nil {
+		return err
+	}
+	*x = tmp
+	return nil
+}
+
+// AppendText appends the textual representation of itself to the end of b
+// (allocating a larger slice if necessary) and returns the updated slice.
+//
+// Implementations must not retain b, nor mutate any bytes within b[:len(b)].
+func (x *PreviewMode) AppendText(b []byte) ([]byte, error) {
+	return append(b, x.String()...), nil
+}
+
+const (
+	// ProgressAuto is a Progress of type Auto.
+	ProgressAuto Progress = 0
+	// ProgressTui is a Progress of type Tui.
+	ProgressTui Progress = 1
+	// ProgressPlain is a Progress of type Plain.
+	ProgressPlain Progress = 2
+	// ProgressJson is a Progress of type Json.
+	ProgressJson Progress = 3
+)
+
+var ErrInvalidProgress = fmt.Errorf("not a valid Progress, try [%s]", strings.Join(_ProgressNames, ", "))
+
+const _ProgressName = "autotuiplainjson"
+
+var _ProgressNames = []string{
+	_ProgressName[0:4],
+	_ProgressName[4:7],
+	_ProgressName[7:12],
+	_ProgressName[12:16],
+}
+
+// ProgressNames returns a list of possible string values of Progress.
+func ProgressNames() []string {
+	tmp := make([]string, len(_ProgressNames))
+	copy(tmp, _ProgressNames)
+	return tmp
+}
+
+// ProgressValues returns a list of the values for Progress
+func ProgressValues() []Progress {
+	return []Progress{
+		ProgressAuto,
+		ProgressTui,
+		ProgressPlain,
+		ProgressJson,
+	}
+}
+
+var _ProgressMap = map[Progress]string{
+	ProgressAuto:  _ProgressName[0:4],
+	ProgressTui:   _ProgressName[4:7],
+	ProgressPlain: _ProgressName[7:12],
+	ProgressJson:  _ProgressName[12:16],
+}
+
+// String implements the Stringer interface.
+func (x Progress) String() string {
+	if str, ok := _ProgressMap[x]; ok {
+		return str
+	}
+	return fmt.Sprintf("Progress(%d)", x)
+}
+
+// IsValid provides a quick way to determine if the typed value is
+// part of the allowed enumerated values
+func (x Progress) IsValid() bool {
+	_, ok := _ProgressMap[x]
+	return ok
+}
+
+var _ProgressValue = map[string]Progress{
+	_ProgressName[0:4]:   ProgressAuto,
+	_ProgressName[4:7]:   ProgressTui,
+	_ProgressName[7:12]:  ProgressPlain,
+	_ProgressName[12:16]: ProgressJson,
+}
+
+// ParseProgress attempts to convert a string to a Progress.
+func ParseProgress(name string) (Progress, error) {
+	if x, ok := _ProgressValue[name]; ok {
+		return x, nil
+	}
+	return Progress(0), fmt.Errorf("%s is %w", name, ErrInvalidProgress)
+}
+
+// MarshalText implements the text marshaller method.
+func (x Progress) MarshalText() ([]byte, error) {
+	return []byte(x.String()), nil
+}
+
+// UnmarshalText implements the text unmarshaller method.
+func (x *Progress) UnmarshalText(text []byte) error {
+	name := string(text)
+	tmp, err := ParseProgress(name)
+	if err != nil {
+		return err
+	}
+	*x = tmp
+	return nil
+}
+
+// AppendText appends the textual representation of itself to the end of b
+// (allocating a larger slice if necessary) and returns the updated slice.
+//
+// Implementations must not retain b, nor mutate any bytes within b[:len(b)].
+func (x *Progress) AppendText(b []byte) ([]byte, error) {
+	return append(b, x.String()...), nil
+}
+
+const (
+	// QuantizerCelebi is a Quantizer of type Celebi.
+	QuantizerCelebi Quantizer = 0
+	// QuantizerWu is a Quantizer of type Wu.
+	QuantizerWu Quantizer = 1
+	// QuantizerKmeans is a Quantizer of type Kmeans.
+	QuantizerKmeans Quantizer = 2
+)
+
+var ErrInvalidQuantizer = fmt.Errorf("not a valid Quantizer, try [%s]", strings.Join(_QuantizerNames, ", "))
+
+const _QuantizerName = "celebiwukmeans"
+
+var _QuantizerNames = []string{
+	_QuantizerName[0:6],
+	_QuantizerName[6:8],
+	_QuantizerName[8:14],
+}
+
+// QuantizerNames returns a list of possible string values of Quantizer.
+func QuantizerNames() []string {
+	tmp := make([]string, len(_QuantizerNames))
+	copy(tmp, _QuantizerNames)
+	return tmp
+}
+
+// QuantizerValues returns a list of the values for Quantizer
+func QuantizerValues() []Quantizer {
+	return []Quantizer{
+		QuantizerCelebi,
+		QuantizerWu,
+		QuantizerKmeans,
+	}
+}
+
+var _QuantizerMap = map[Quantizer]string{
+	QuantizerCelebi: _QuantizerName[0:6],
+	QuantizerWu:     _QuantizerName[6:8],
+	QuantizerKmeans: _QuantizerName[8:14],
+}
+
+// String implements the Stringer interface.
+func (x Quantizer) String() string {
+	if str, ok := _QuantizerMap[x]; ok {
+		return str
+	}
+	return fmt.Sprintf("Quantizer(%d)", x)
+}
+
+// IsValid provides a quick way to determine if the typed value is
+// part of the allowed enumerated values
+func (x Quantizer) IsValid() bool {
+	_, ok := _QuantizerMap[x]
+	return ok
+}
+
+var _QuantizerValue = map[string]Quantizer{
+	_QuantizerName[0:6]:  QuantizerCelebi,
+	_QuantizerName[6:8]:  QuantizerWu,
+	_QuantizerName[8:14]: QuantizerKmeans,
+}
+
+// ParseQuantizer attempts to convert a string to a Quantizer.
+func ParseQuantizer(name string) (Quantizer, error) {
+	if x, ok := _QuantizerValue[name]; ok {
+		return x, nil
+	}
+	return Quantizer(0), fmt.Errorf("%s is %w", name, ErrInvalidQuantizer)
+}
+
+// MarshalText implements the text marshaller method.
+func (x Quantizer) MarshalText() ([]byte, error) {
+	return []byte(x.String()), nil
+}
+
+// UnmarshalText implements the text unmarshaller method.
+func (x *Quantizer) UnmarshalText(text []byte) error {
+	name := string(text)
+	tmp, err := ParseQuantizer(name)
+	if err != nil {
+		return err
+	}
+	*x = tmp
+	return nil
+}
+
+// AppendText appends the textual representation of itself to the end of b
+// (allocating a larger slice if necessary) and returns the updated slice.
+//
+// Implementations must not retain b, nor mutate any bytes within b[:len(b)].
+func (x *Quantizer) AppendText(b []byte) ([]byte, error) {
+	return append(b, x.String()...), nil
+}
+
+const (
+	// SamplingWindow is a Sampling of type Window.
+	SamplingWindow Sampling = 0
+	// SamplingUniform is a Sampling of type Uniform.
+	SamplingUniform Sampling = 1
+	// SamplingScene is a Sampling of type Scene.
+	SamplingScene Sampling = 2
+	// SamplingKeyframes is a Sampling of type Keyframes.
+	SamplingKeyframes Sampling = 3
+)
+
+var ErrInvalidSampling = fmt.Errorf("not a valid Sampling, try [%s]", strings.Join(_SamplingNames, ", "))
+
+const _SamplingName = "windowuniformscenekeyframes"
+
+var _SamplingNames = []string{
+	_SamplingName[0:6],
+	_SamplingName[6:13],
+	_SamplingName[13:18],
+	_SamplingName[18:27],
+}
+
+// SamplingNames returns a list of possible string values of Sampling.
+func SamplingNames() []string {
+	tmp := make([]string, len(_SamplingNames))
+	copy(tmp, _SamplingNames)
+	return tmp
+}
+
+// SamplingValues returns a list of the values for Sampling
+func SamplingValues() []Sampling {
+	return []Sampling{
+		SamplingWindow,
+		SamplingUniform,
+		SamplingScene,
+		SamplingKeyframes,
+	}
+}
+
+var _SamplingMap = map[Sampling]string{
+	SamplingWindow:    _SamplingName[0:6],
+	SamplingUniform:   _SamplingName[6:13],
+	SamplingScene:     _SamplingName[13:18],
+	SamplingKeyframes: _SamplingName[18:27],
+}
+
+// String implements the Stringer interface.
+func (x Sampling) String() string {
+	if str, ok := _SamplingMap[x]; ok {
+		return str
+	}
+	return fmt.Sprintf("Sampling(%d)", x)
+}
+
+// IsValid provides a quick way to determine if the typed value is
+// part of the allowed enumerated values
+func (x Sampling) IsValid() bool {
+	_, ok := _SamplingMap[x]
+	return ok
+}
+
+var _SamplingValue = map[string]Sampling{
+	_SamplingName[0:6]:   SamplingWindow,
+	_SamplingName[6:13]:  SamplingUniform,
+	_SamplingName[13:18]: SamplingScene,
+	_SamplingName[18:27]: SamplingKeyframes,
+}
+
+// ParseSampling attempts to convert a string to a Sampling.
+func ParseSampling(name string) (Sampling, error) {
+	if x, ok := _SamplingValue[name]; ok {
+		return x, nil
+	}
+	return Sampling(0), fmt.Errorf("%s is %w", name, ErrInvalidSampling)
+}
+
+// MarshalText implements the text marshaller method.
+func (x Sampling) MarshalText() ([]byte, error) {
+	return []byte(x.String()), nil
+}
+
+// UnmarshalText implements the text unmarshaller method.
+func (x *Sampling) UnmarshalText(text []byte) error {
+	name := string(text)
+	tmp, err := ParseSampling(name)
+	if err != nil {
+		return err
+	}
+	*x = tmp
+	return nil
+}
+
+// AppendText appends the textual representation of itself to the end of b
+// (allocating a larger slice if necessary) and returns the updated slice.
+//
+// Implementations must not retain b, nor mutate any bytes within b[:len(b)].
+func (x *Sampling) AppendText(b []byte) ([]byte, error) {
+	return append(b, x.String()...), nil
+}
@@ -1,14 +1,17 @@
 package config
 
 import (
+	"context"
 	"fmt"
 	"log/slog"
+	"maps"
 	"math"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/Nadim147c/material/v3/color"
+	"github.com/Nadim147c/rong/v5/internal/config/enums"
 	"github.com/carapace-sh/carapace"
 	"github.com/spf13/cast"
 	"github.com/spf13/pflag"
@@ -23,6 +26,9 @@ type option[T any] struct {
 	desc    string
 	typeStr string
 	caster  func(any) (T, error)
+	// store is the store flags of a bound option set values in. Nil is the
+	// global store.
+	store *viper.Viper
 }
 
 // Default returns the default value of the option.
@@ -30,9 +36,29 @@ func (o *option[T]) Default() T {
 	return o.defval
 }
 
-// SetValue sets the current value in the configuration store.
-func (o *option[T]) SetValue(v T) {
-	viper.Set(o.key, v)
+// SetValue sets the current value in the configuration store of ctx.
+func (o *option[T]) SetValue(ctx context.Context, v T) {
+	Store(ctx).Set(o.key, v)
+}
+
+// flagStore returns the store flags of the option set values in.
+func (o *option[T]) flagStore() *viper.Viper {
+	if o.store != nil {
+		return o.store
+	}
+	return viper.GetViper()
+}
+
+// bind returns a copy of the option whose flags set values in v.
+func (o *option[T]) bind(v *viper.Viper) *option[T] {
+	c := *o
+	c.store = v
+	return &c
+}
+
+// Bind implements Flag.
+func (o *option[T]) Bind(v *viper.Viper) Flag {
+	return o.bind(v)
 }
 
 func isBool(a any) bool {
@@ -43,10 +69,15 @@ func isBool(a any) bool {
 	return s == "true" || s == "false"
 }
 
-// Value retrieves the current value from the configuration store. Returns the
-// default value if conversion fails.
-func (o *option[T]) Value() T {
-	viperValue := viper.Get(o.key)
+// Value retrieves the current value from the configuration store of ctx.
+// Returns the default value if conversion fails.
+func (o *option[T]) Value(ctx context.Context) T {
+	return o.get(Store(ctx))
+}
+
+// get retrieves the current value from store.
+func (o *option[T]) get(store *viper.Viper) T {
+	viperValue := store.Get(o.key)
 	slog.Debug("Config key accessed", "key", o.Key(), "raw-value", viperValue)
 	if v, ok := viperValue.(T); ok {
 		return v
@@ -88,7 +119,7 @@ func (o *option[T]) Set(s string) error {
 		return err
 	}
 
-	viper.Set(o.key, v)
+	o.flagStore().Set(o.key, v)
 	return nil
 }
 
@@ -96,7 +127,7 @@ func (o *option[T]) Set(s string) error {
 // WARNING: This method is only for pflag interface compatibility.
 // Do not use for general string representation.
 func (o *option[T]) String() string {
-	return cast.ToString(o.Value())
+	return cast.ToString(o.get(o.flagStore()))
 }
 
 // Type returns the type description for pflag.
@@ -138,6 +169,7 @@ func newOption[T any](
 		desc += "."
 	}
 
+	defaults[key] = defval
 	viper.SetDefault(key, defval)
 	return &option[T]{
 		key:     key,
@@ -168,7 +200,7 @@ func (c *boolOption) RegisterFlag(set *pflag.FlagSet) {
 		if err != nil {
 			return err
 		}
-		c.SetValue(!b)
+		c.flagStore().Set(c.key, !b)
 		return nil
 	})
 
@@ -181,7 +213,7 @@ func (c *boolOption) RegisterFlag(set *pflag.FlagSet) {
 // Set implements pflag.Value for boolean flag.
 func (c *boolOption) Set(s string) error {
 	if s == "<bool>" {
-		c.SetValue(true)
+		c.flagStore().Set(c.key, true)
 		return nil
 	}
 
@@ -190,10 +222,15 @@ func (c *boolOption) Set(s string) error {
 		return err
 	}
 
-	c.SetValue(b)
+	c.flagStore().Set(c.key, b)
 	return nil
 }
 
+// Bind implements Flag.
+func (c *boolOption) Bind(v *viper.Viper) Flag {
+	return &boolOption{c.bind(v)}
+}
+
 // String returns empty string for boolean flags.
 // WARNING: This method is only for pflag interface compatibility.
 func (c *boolOption) String() string { return "" }
@@ -218,11 +255,26 @@ func newIntOption(short, key string, defval int, desc string) *option[int] {
 // castDuration casts the type to time.duration. The differenace between
 // castDuration and cast.ToDurationE is the default duration unit is second in
 // castDuration where cast.ToDurationE uses nanoseocond. It make sense to go dev
-// to use nanoseocond but everyone else expect second as default time.
+// to use nanoseocond but everyone else expect second as default time. A "d"
+// suffix is also accepted for days, and numbers of config files are seconds.
 func castDuration(a any) (time.Duration, error) {
 	s, ok := a.(string)
 	if !ok {
-		return 0, fmt.Errorf("failed to convert %v to duration", a) //nolint
+		if d, ok := a.(time.Duration); ok {
+			return d, nil
+		}
+		f, err := cast.ToFloat64E(a)
+		if err != nil {
+			return 0, fmt.Errorf("failed to convert %v to duration", a) //nolint
+		}
+		return time.Duration(f * float64(time.Second)), nil
+	}
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		f, err := strconv.ParseFloat(days, 64)
+		if err != nil {
+			return 0, err
+		}
+		return time.Duration(f * float64(24*time.Hour)), nil
 	}
 	if !strings.ContainsAny(s, "nsuµmh") {
 		s += "s"
@@ -235,6 +287,38 @@ func newDurationOption(short, key string, defval time.Duration, desc string) *op
 	return newOption(short, key, defval, desc, "duration", castDuration)
 }
 
+// castSize casts the type to a size in bytes. Strings may use a K, M, G or T
+// suffix with optional "iB" or "B" (e.g. 512M or 2GiB), in powers of 1024.
+func castSize(a any) (int64, error) {
+	s, ok := a.(string)
+	if !ok {
+		return cast.ToInt64E(a)
+	}
+
+	s = strings.ToUpper(strings.TrimSpace(s))
+	s = strings.TrimSuffix(strings.TrimSuffix(s, "B"), "I")
+
+	unit := int64(1)
+	if i := strings.IndexAny(s, "KMGT"); i > 0 && i == len(s)-1 {
+		unit <<= 10 * (strings.IndexByte("KMGT", s[i]) + 1)
+		s = s[:i]
+	}
+
+	f, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	if err != nil {
+		return 0, err
+	}
+	if f < 0 {
+		return 0, fmt.Errorf("size %q must not be negative", a) //nolint
+	}
+	return int64(f * float64(unit)), nil
+}
+
+// newSizeOption creates a new size configuration option.
+func newSizeOption(short, key string, defval int64, desc string) *option[int64] {
+	return newOption(short, key, defval, desc, "size", castSize)
+}
+
 // formatFloat formats float with 2 decimal precision.
 func formatFloat(v float64) string {
 	// round to 2 decimals first
@@ -262,6 +346,40 @@ func newFloatOption(short, key string, defval float64, desc string) *option[floa
 	return newOption(short, key, defval, desc, "float", cast.ToFloat64E)
 }
 
+// stringsOption represents a list of strings.
+type stringsOption struct{ *option[[]string] }
+
+// RegisterFlag registers the option with a flag set.
+func (o *stringsOption) RegisterFlag(set *pflag.FlagSet) {
+	set.VarP(o, o.key, o.short, o.desc)
+}
+
+// Set implements pflag.Value for lists. Values are appended to the configured
+// list and can be separated by commas.
+func (o *stringsOption) Set(s string) error {
+	store := o.flagStore()
+	store.Set(o.key, append(o.get(store), strings.Split(s, ",")...))
+	return nil
+}
+
+// String returns the comma separated list.
+// WARNING: This method is only for pflag interface compatibility.
+func (o *stringsOption) String() string {
+	return strings.Join(o.get(o.flagStore()), ",")
+}
+
+// Bind implements Flag.
+func (o *stringsOption) Bind(v *viper.Viper) Flag {
+	return &stringsOption{o.bind(v)}
+}
+
+// newStringsOption creates a new string list configuration option.
+func newStringsOption(short, key string, defval []string, desc string) *stringsOption {
+	return &stringsOption{
+		option: newOption(short, key, defval, desc, "strings", cast.ToStringSliceE),
+	}
+}
+
 // colorOption represents a color configuration option.
 type colorOption struct{ *option[color.ARGB] }
 
@@ -275,10 +393,15 @@ func (c *colorOption) RegisterFlag(set *pflag.FlagSet) {
 // WARNING: This method is only for pflag interface compatibility.
 // For general use, prefer Value().String() or Value().AnsiBg().
 func (c colorOption) String() string {
-	col := c.Value()
+	col := c.get(c.flagStore())
 	return col.AnsiFg(col.String())
 }
 
+// Bind implements Flag.
+func (c *colorOption) Bind(v *viper.Viper) Flag {
+	return &colorOption{c.bind(v)}
+}
+
 // newColorOption creates a new color configuration option.
 func newColorOption(short, key, defval, desc string) *colorOption {
 	return &colorOption{
@@ -305,19 +428,25 @@ func (c *countOption) RegisterFlag(set *pflag.FlagSet) {
 
 // Set implements pflag.Value for count flags.
 func (c *countOption) Set(s string) error {
+	store := c.flagStore()
 	if s == "<count>" {
 		// Increment the current value
-		c.SetValue(viper.GetInt(c.key) + 1)
+		store.Set(c.key, store.GetInt(c.key)+1)
 		return nil
 	}
 	i, err := c.caster(s)
 	if err != nil {
 		return err
 	}
-	c.SetValue(i)
+	store.Set(c.key, i)
 	return nil
 }
 
+// Bind implements Flag.
+func (c *countOption) Bind(v *viper.Viper) Flag {
+	return &countOption{c.bind(v)}
+}
+
 // newCountOption creates a new count configuration option.
 func newCountOption(short, key string, desc string) *countOption {
 	return &countOption{
@@ -392,10 +521,23 @@ func (o *kvOption[T]) Set(s string) error {
 		return err
 	}
 
-	viper.Set(o.key+"."+name, v)
+	// Names are kept in a single map since the store splits keys on dots,
+	// which patterns like *.ext or image/vnd.adobe.photoshop contain.
+	store := o.flagStore()
+	m := map[string]T{}
+	if current, ok := store.Get(o.key).(map[string]T); ok {
+		maps.Copy(m, current)
+	}
+	m[name] = v
+	store.Set(o.key, m)
 	return nil
 }
 
+// Bind implements Flag.
+func (o *kvOption[T]) Bind(v *viper.Viper) Flag {
+	return &kvOption[T]{option: o.bind(v), caster: o.caster}
+}
+
 // newKvOption creates a new enumeration configuration option.
 func newKvOption[T any](
 	short, key string,
@@ -428,3 +570,77 @@ func newKvOption[T any](
 		}),
 	}
 }
+
+// ExternalDecoder is a command decoding media files to images on its stdout.
+type ExternalDecoder struct {
+	// Command is the command template. {input} is replaced with the path of
+	// the file and {size} with the size of decoded frames.
+	Command string
+	// Format is the format of images written by the command.
+	Format enums.DecoderFormat
+	// Timeout is the maximum run time of the command. Zero uses the
+	// decode-timeout option.
+	Timeout time.Duration
+}
+
+// parseExternalDecoder parses an external decoder from a command template or a
+// table with command, format and timeout keys.
+func parseExternalDecoder(a any) (ExternalDecoder, error) {
+	if s, ok := a.(string); ok {
+		return ExternalDecoder{Command: s}, nil
+	}
+
+	table, err := cast.ToStringMapE(a)
+	if err != nil {
+		return ExternalDecoder{}, err
+	}
+
+	var d ExternalDecoder
+	d.Command, err = cast.ToStringE(table["command"])
+	if err != nil || d.Command == "" {
+		return d, fmt.Errorf("decoder %v must have a command", a)
+	}
+	if format, ok := table["format"]; ok {
+		d.Format, err = enums.ParseDecoderFormat(cast.ToString(format))
+		if err != nil {
+			return d, err
+		}
+	}
+	if timeout, ok := table["timeout"]; ok {
+		d.Timeout, err = castDuration(timeout)
+		if err != nil {
+			return d, err
+		}
+	}
+	return d, nil
+}
+
+// newDecodersOption creates an option of external decoders by pattern. Flags
+// set commands writing ppm images as pattern=command.
+func newDecodersOption(short, key, desc string) *kvOption[ExternalDecoder] {
+	return &kvOption[ExternalDecoder]{
+		caster: func(s string) (ExternalDecoder, error) {
+			return parseExternalDecoder(s)
+		},
+		option: newOption(short, key, map[string]ExternalDecoder(nil), desc, "pattern=command", func(a any) (map[string]ExternalDecoder, error) {
+			raw, err := cast.ToStringMapE(a)
+			if err != nil {
+				return nil, err
+			}
+
+			m := make(map[string]ExternalDecoder, len(raw))
+			for pattern, value := range raw {
+				if d, ok := value.(ExternalDecoder); ok {
+					m[pattern] = d
+					continue
+				}
+				d, err := parseExternalDecoder(value)
+				if err != nil {
+					return nil, fmt.Errorf("decoder of %q: %w", pattern, err)
+				}
+				m[pattern] = d
+			}
+			return m, nil
+		}),
+	}
+}
@@ -0,0 +1,109 @@
+package config
+
+import (
+	"maps"
+	"testing"
+	"time"
+
+	"github.com/Nadim147c/rong/v5/internal/config/enums"
+)
+
+func TestDecodersFlag(t *testing.T) {
+	testdata := []struct {
+		name string
+		args []string
+		want map[string]string
+	}{
+		{"extension", []string{"--decoders=nef=dcraw -c {input}"}, map[string]string{
+			"nef": "dcraw -c {input}",
+		}},
+		{"dotted patterns", []string{
+			"--decoders=image/vnd.adobe.photoshop=magick {input} ppm:-",
+			"--decoders=*.bak=cat",
+		}, map[string]string{
+			"image/vnd.adobe.photoshop": "magick {input} ppm:-",
+			"*.bak":                     "cat",
+		}},
+		{"later flags replace patterns", []string{"--decoders=nef=a", "--decoders=nef=b"}, map[string]string{
+			"nef": "b",
+		}},
+	}
+
+	for _, test := range testdata {
+		t.Run(test.name, func(t *testing.T) {
+			store := New()
+			set := NewFlagSet("test", []Flag{Decoders}, store)
+			if err := set.Parse(test.args); err != nil {
+				t.Fatal(err)
+			}
+
+			got := map[string]string{}
+			for pattern, d := range Decoders.Value(WithStore(t.Context(), store)) {
+				got[pattern] = d.Command
+			}
+			if !maps.Equal(got, test.want) {
+				t.Errorf("decoders = %v, want %v", got, test.want)
+			}
+		})
+	}
+
+	if err := NewFlagSet("test", []Flag{Decoders}, New()).Parse([]string{"--decoders=nef"}); err == nil {
+		t.Error("flag without a command was accepted")
+	}
+}
+
+func TestParseExternalDecoder(t *testing.T) {
+	testdata := []struct {
+		name  string
+		value any
+		want  ExternalDecoder
+		err   bool
+	}{
+		{"command", "dcraw -c {input}", ExternalDecoder{Command: "dcraw -c {input}"}, false},
+		{
+			"table",
+			map[string]any{"command": "magick {input} rgb:-", "format": "rgb24", "timeout": "1m"},
+			ExternalDecoder{Command: "magick {input} rgb:-", Format: enums.DecoderFormatRgb24, Timeout: time.Minute},
+			false,
+		},
+		{
+			"bare number timeout",
+			map[string]any{"command": "dcraw", "timeout": int64(5)},
+			ExternalDecoder{Command: "dcraw", Timeout: 5 * time.Second},
+			false,
+		},
+		{
+			"fractional timeout",
+			map[string]any{"command": "dcraw", "timeout": 2.5},
+			ExternalDecoder{Command: "dcraw", Timeout: 2500 * time.Millisecond},
+			false,
+		},
+		{
+			"string number timeout",
+			map[string]any{"command": "dcraw", "timeout": "30"},
+			ExternalDecoder{Command: "dcraw", Timeout: 30 * time.Second},
+			false,
+		},
+		{"missing command", map[string]any{"timeout": "1m"}, ExternalDecoder{}, true},
+		{"unknown format", map[string]any{"command": "dcraw", "format": "tiff"}, ExternalDecoder{}, true},
+		{"invalid timeout", map[string]any{"command": "dcraw", "timeout": "soon"}, ExternalDecoder{}, true},
+	}
+
+	for _, test := range testdata {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := parseExternalDecoder(test.value)
+			if test.err {
+				if err == nil {
+					t.Errorf("parseExternalDecoder = %+v, want an error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != test.want {
+				t.Errorf("parseExternalDecoder = %+v, want %+v", got, test.want)
+			}
+		})
+	}
+}
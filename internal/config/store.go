@@ -0,0 +1,93 @@
+package config
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+
+	"github.com/Nadim147c/rong/v5/internal/pathutil"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+// defaults contains default values of all registered options.
+var defaults = map[string]any{}
+
+type storeKey struct{}
+
+// WithStore returns a copy of ctx whose options are read from and written to v.
+func WithStore(ctx context.Context, v *viper.Viper) context.Context {
+	return context.WithValue(ctx, storeKey{}, v)
+}
+
+// Store returns the configuration store of ctx. It is the global viper
+// instance unless another store has been set with WithStore.
+func Store(ctx context.Context) *viper.Viper {
+	if v, ok := ctx.Value(storeKey{}).(*viper.Viper); ok {
+		return v
+	}
+	return viper.GetViper()
+}
+
+// New creates an empty configuration store with default values of all options.
+func New() *viper.Viper {
+	v := viper.New()
+	for key, value := range defaults {
+		v.SetDefault(key, value)
+	}
+	return v
+}
+
+// Flag is an option that can be registered as a command line flag.
+type Flag interface {
+	// RegisterFlag registers the option with a flag set.
+	RegisterFlag(set *pflag.FlagSet)
+	// Bind returns a copy of the option whose flags set values in v instead
+	// of the global store.
+	Bind(v *viper.Viper) Flag
+}
+
+// Flags returns the options registered as flags of sets.
+func Flags(sets ...*pflag.FlagSet) []Flag {
+	var flags []Flag
+	for _, set := range sets {
+		set.VisitAll(func(f *pflag.Flag) {
+			if o, ok := f.Value.(Flag); ok {
+				flags = append(flags, o)
+			}
+		})
+	}
+	return flags
+}
+
+// NewFlagSet creates a flag set of flags that set values in v.
+func NewFlagSet(name string, flags []Flag, v *viper.Viper) *pflag.FlagSet {
+	set := pflag.NewFlagSet(name, pflag.ContinueOnError)
+	for _, f := range flags {
+		f.Bind(v).RegisterFlag(set)
+	}
+	return set
+}
+
+// Load reads the environment and the configuration file into the store of
+// ctx. The file is chosen by the config option, falling back to the default
+// config locations.
+func Load(ctx context.Context) error {
+	v := Store(ctx)
+	v.SetEnvPrefix("rong")
+	v.AutomaticEnv()
+
+	if value := Config.Value(ctx); value != "" {
+		slog.Info("Cofniguration path has been set", "value", value)
+		if slices.Contains([]string{"no", "0", "false"}, value) {
+			return nil
+		}
+		v.SetConfigFile(value)
+	} else {
+		v.AddConfigPath("/etc/rong")
+		v.AddConfigPath(pathutil.ConfigDir)
+		v.SetConfigName("config")
+	}
+
+	return v.ReadInConfig()
+}
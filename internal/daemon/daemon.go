@@ -0,0 +1,191 @@
+// Package daemon implements the protocol used by rong daemon and the clients
+// forwarding commands to it. Requests and responses are JSON objects sent over
+// a unix socket, one per connection.
+package daemon
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/Nadim147c/rong/v5/internal/config"
+	"github.com/Nadim147c/rong/v5/internal/media"
+	"github.com/Nadim147c/rong/v5/internal/pathutil"
+	"github.com/spf13/cobra"
+)
+
+// Commands are the commands the daemon can run.
+var Commands = []string{"image", "video", "color", "regen"}
+
+// SocketPath is the unix socket daemon listens on.
+var SocketPath = filepath.Join(pathutil.RuntimeDir, "daemon.sock")
+
+// ErrRemote means the daemon failed to run the request.
+var ErrRemote = errors.New("daemon request failed")
+
+// Request asks the daemon to run a command.
+type Request struct {
+	// Command is the name of the command, e.g. image.
+	Command string `json:"command"`
+	// Args are flags and positional arguments of the command.
+	Args []string `json:"args"`
+	// Dir is the working directory used to resolve relative paths.
+	Dir string `json:"dir"`
+}
+
+// Response is the result of a Request.
+type Response struct {
+	// Output is what the command wrote to stdout.
+	Output string `json:"output"`
+	// Error is the error returned by the command, if any.
+	Error string `json:"error,omitempty"`
+}
+
+// Handler runs a request and writes the command output to w.
+type Handler func(ctx context.Context, req Request, w io.Writer) error
+
+// Listen creates the daemon socket. A socket left behind by a daemon that is
+// no longer running is removed.
+func Listen() (net.Listener, error) {
+	if err := os.MkdirAll(filepath.Dir(SocketPath), 0o700); err != nil {
+		return nil, err
+	}
+
+	if conn, err := net.DialTimeout("unix", SocketPath, time.Second); err == nil {
+		conn.Close()
+		return nil, fmt.Errorf("daemon is already listening on %s", SocketPath) //nolint
+	}
+
+	if err := os.Remove(SocketPath); err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	return net.Listen("unix", SocketPath)
+}
+
+// Serve accepts connections on ln until ctx is cancelled. Each connection is
+// handled concurrently.
+func Serve(ctx context.Context, ln net.Listener, handle Handler) error {
+	go func() {
+		<-ctx.Done()
+		ln.Close()
+	}()
+
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		go serveConn(ctx, conn, handle)
+	}
+}
+
+func serveConn(ctx context.Context, conn net.Conn, handle Handler) {
+	defer conn.Close()
+
+	var req Request
+	if err := json.NewDecoder(conn).Decode(&req); err != nil {
+		slog.Error("Failed to decode request", "error", err)
+		return
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// Client closes the connection when it is interrupted.
+	go func() {
+		_, _ = io.Copy(io.Discard, conn)
+		cancel()
+	}()
+
+	slog.Info("Handling request", "command", req.Command, "args", req.Args)
+
+	var resp Response
+	var out bytes.Buffer
+	if !slices.Contains(Commands, req.Command) {
+		resp.Error = fmt.Sprintf("unknown command %q", req.Command)
+	} else if err := handle(ctx, req, &out); err != nil {
+		resp.Error = err.Error()
+	}
+	resp.Output = out.String()
+
+	if err := json.NewEncoder(conn).Encode(resp); err != nil {
+		slog.Error("Failed to send response", "error", err)
+	}
+}
+
+// Forward sends cmd with the arguments rong was started with to a running
+// daemon. It reports false when the command should run locally instead:
+// forwarding is disabled, the command is not supported or no daemon is
+// running.
+func Forward(ctx context.Context, cmd *cobra.Command) (bool, error) {
+	if !config.Daemon.Value(ctx) || !slices.Contains(Commands, cmd.Name()) {
+		return false, nil
+	}
+	if config.Pick.Value(ctx) {
+		return false, nil // the picker needs the terminal of this process
+	}
+
+	found, args, err := cmd.Root().Find(os.Args[1:])
+	if err != nil || found != cmd {
+		return false, nil //nolint:nilerr // not started from command line
+	}
+	if slices.ContainsFunc(args, media.IsStdin) {
+		return false, nil // only this process can read its standard input
+	}
+
+	var dialer net.Dialer
+	dialCtx, cancel := context.WithTimeout(ctx, 100*time.Millisecond)
+	defer cancel()
+	conn, err := dialer.DialContext(dialCtx, "unix", SocketPath)
+	if err != nil {
+		return false, nil //nolint:nilerr // daemon is not running
+	}
+	defer conn.Close()
+
+	go func() {
+		<-ctx.Done()
+		conn.Close()
+	}()
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		return true, err
+	}
+
+	slog.Info("Forwarding command to daemon", "socket", SocketPath, "command", cmd.Name())
+
+	req := Request{Command: cmd.Name(), Args: args, Dir: cwd}
+	if err := json.NewEncoder(conn).Encode(req); err != nil {
+		return true, fmt.Errorf("failed to send request to daemon: %w", err)
+	}
+
+	var resp Response
+	if err := json.NewDecoder(conn).Decode(&resp); err != nil {
+		if ctx.Err() != nil {
+			return true, ctx.Err()
+		}
+		return true, fmt.Errorf("failed to read daemon response: %w", err)
+	}
+
+	if _, err := io.WriteString(cmd.OutOrStdout(), resp.Output); err != nil {
+		return true, err
+	}
+
+	if resp.Error != "" {
+		return true, fmt.Errorf("%w: %s", ErrRemote, resp.Error)
+	}
+	return true, nil
+}
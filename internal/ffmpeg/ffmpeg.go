@@ -1,137 +1,222 @@
 package ffmpeg
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
+	"image"
+	"io"
 	"math"
 	"os/exec"
+	"regexp"
 	"strconv"
 	"strings"
 
-	"github.com/Nadim147c/material/v3/color"
-	"github.com/gabriel-vasile/mimetype"
+	"github.com/Nadim147c/rong/v5/internal/ppm"
 )
 
-// GetPixels decodes media using ffmpeg and returns slices of pixels for given
-// maxFrames numbers.
-func GetPixels(
-	ctx context.Context,
-	path string,
-	maxFrames int,
-	maxDuration float64,
-) ([]color.ARGB, error) {
-	var pixels []color.ARGB
-
-	mtype, err := mimetype.DetectFile(path)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get media type: %w", err)
+// ErrMissing means ffmpeg or ffprobe is not installed.
+var ErrMissing = errors.New("not installed")
+
+// command returns the command of ffmpeg or ffprobe, or an error explaining
+// what needs it when it is not installed.
+func command(ctx context.Context, name string, args ...string) (*exec.Cmd, error) {
+	if _, err := exec.LookPath(name); err != nil {
+		return nil, fmt.Errorf(
+			"%s is %w: it is required for videos and images like AVIF, HEIC or JPEG XL",
+			name, ErrMissing,
+		)
 	}
+	return exec.CommandContext(ctx, name, args...), nil
+}
 
-	kind := mtype.String()
+// Options configures decoding with ffmpeg.
+type Options struct {
+	Sampling
+	// Crop is a crop filter applied to frames before they are scaled.
+	Crop string
+	// MaxSize is the maximum width and height of decoded frames. Larger frames
+	// are scaled down by ffmpeg keeping their aspect ratio. Zero keeps the size.
+	MaxSize int
+}
 
-	if !strings.HasPrefix(kind, "video") && !strings.HasPrefix(kind, "image") {
-		return pixels, fmt.Errorf("invalid media type: %s", kind) //nolint
+// filter returns the filter graph applied to decoded frames.
+func (o Options) filter() string {
+	var filters []string
+	if o.Crop != "" {
+		filters = append(filters, o.Crop)
 	}
+	if o.MaxSize > 0 {
+		filters = append(filters, fmt.Sprintf(
+			"scale=w='min(iw,%[1]d)':h='min(ih,%[1]d)':force_original_aspect_ratio=decrease:flags=area",
+			o.MaxSize,
+		))
+	}
+	return strings.Join(filters, ",")
+}
 
-	if strings.HasPrefix(kind, "image") {
-		ffmpeg := exec.CommandContext(ctx, "ffmpeg",
-			"-i", path,
-			"-vframes", "1",
-			"-f", "rawvideo",
-			"-pix_fmt", "rgb24",
-			"-")
-
-		out, err := ffmpeg.Output()
-		if err != nil {
-			return pixels, fmt.Errorf(
-				"failed to get pixels from ffmpeg command: %w",
-				err,
-			)
-		}
-
-		totalBytes := len(out)
-
-		pixels := make([]color.ARGB, 0, totalBytes/3)
-		for i := 0; i+2 < totalBytes; i += 3 {
-			c := color.ARGBFromRGB(out[i], out[i+1], out[i+2])
-			pixels = append(pixels, c)
-		}
+// ptsPattern matches the time of a frame logged by the showinfo filter.
+var ptsPattern = regexp.MustCompile(`pts_time:\s*(-?[0-9.]+)`)
 
-		return pixels, nil
+// frameTime returns the time of the first frame logged by the showinfo filter
+// in log, or NaN when there is none.
+func frameTime(log string) float64 {
+	m := ptsPattern.FindStringSubmatch(log)
+	if m == nil {
+		return math.NaN()
 	}
-
-	duration, err := GetDuration(path)
+	t, err := strconv.ParseFloat(m[1], 64)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get duration: %w", err)
+		return math.NaN()
 	}
+	return t
+}
 
-	duration = min(duration, maxDuration)
-
-	fps := float64(maxFrames) / duration
+// collapse returns the weight of the frame decoded at time t for the first of
+// samples, and the number of samples it stands for. Later samples whose time
+// is not after the frame would decode the same frame again, so their weight is
+// added to it instead. A frame with an unknown time only stands for its own
+// sample.
+func collapse(samples []Sample, t float64) (float64, int) {
+	weight, n := samples[0].Weight, 1
+	for n < len(samples) && samples[n].Time <= t+1e-6 {
+		weight += samples[n].Weight
+		n++
+	}
+	return weight, n
+}
 
-	if math.Floor(duration) < float64(maxFrames) {
-		fps = 1
+// Frames decodes media using ffmpeg and calls fn with every decoded frame and
+// the share of the media it stands for. Images have a single frame and frames
+// of videos are picked by sampling. Every sampled frame is decoded by seeking
+// the input, so only the frames around it are read, and samples that select
+// the same frame share it. Frames are scaled down by ffmpeg and streamed, so
+// memory use doesn't depend on the length or resolution of the media. The
+// image passed to fn is reused for the next frame.
+func Frames(
+	ctx context.Context,
+	path string,
+	opts Options,
+	fn func(img image.Image, weight float64) error,
+) error {
+	info, err := Probe(ctx, path)
+	if err != nil {
+		return err
 	}
 
-	filterGraph := fmt.Sprintf("fps=%.8f", fps)
-	processTime := fmt.Sprintf("%.5f", duration)
+	d := &decoder{}
 
-	ffmpeg := exec.CommandContext(ctx, "ffmpeg",
-		"-i", path,
-		"-vf", filterGraph,
-		"-t", processTime,
-		"-f", "rawvideo",
-		"-pix_fmt", "rgb24",
-		"-")
+	if info.Still() {
+		args := []string{"-i", path, "-vframes", "1"}
+		_, err := d.decode(ctx, args, opts.filter(), func(img image.Image) error {
+			return fn(img, 1)
+		})
+		return err
+	}
 
-	out, err := ffmpeg.Output()
+	samples, err := Samples(ctx, path, info, opts.Sampling)
 	if err != nil {
-		return pixels, fmt.Errorf("failed to process image: %w", err)
+		return err
 	}
 
-	totalBytes := len(out)
+	// showinfo logs the time of the decoded frame. Timestamps are kept from
+	// the input, starting at zero, to compare them with the time of samples.
+	filter := "showinfo"
+	if f := opts.filter(); f != "" {
+		filter += "," + f
+	}
 
-	pixels = make([]color.ARGB, 0, totalBytes/3)
-	for i := 0; i+2 < totalBytes; i += 3 {
-		c := color.ARGBFromRGB(out[i], out[i+1], out[i+2])
-		pixels = append(pixels, c)
+	for i := 0; i < len(samples); {
+		args := []string{
+			"-copyts", "-start_at_zero",
+			"-ss", fmt.Sprintf("%.5f", samples[i].Time),
+			"-i", path,
+			"-frames:v", "1",
+		}
+		var frame image.Image
+		log, err := d.decode(ctx, args, filter, func(img image.Image) error {
+			frame = img
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		if frame == nil {
+			i++ // the sample is past the last frame
+			continue
+		}
+
+		weight, n := collapse(samples[i:], frameTime(log))
+		if err := fn(frame, weight); err != nil {
+			return err
+		}
+		i += n
 	}
+	return nil
+}
 
-	return pixels, nil
+// decoder decodes frames with ffmpeg. Buffers of frames are reused across
+// calls of decode.
+type decoder struct {
+	ppm ppm.Reader
 }
 
-// GetDuration runs ffprobe to determine if the file is an image or video and
-// returns its duration.
-func GetDuration(src string) (float64, error) {
-	//nolint // its too fast
-	out, err := exec.Command("ffprobe",
-		"-v", "error",
-		"-show_entries", "format=duration",
-		"-of", "default=noprint_wrappers=1:nokey=1",
-		src,
-	).Output()
+// decode runs ffmpeg with input args and the filter graph filter, and reads
+// its output as a stream of ppm images. The log of ffmpeg is returned.
+func (d *decoder) decode(
+	ctx context.Context,
+	args []string,
+	filter string,
+	fn func(image.Image) error,
+) (string, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	if filter != "" {
+		args = append(args, "-vf", filter)
+	}
+	args = append(args, "-f", "image2pipe", "-vcodec", "ppm", "-")
+	ffmpeg, err := command(ctx, "ffmpeg", args...)
 	if err != nil {
-		return 0, err
+		return "", err
 	}
 
-	return strconv.ParseFloat(strings.TrimSpace(string(out)), 64)
-}
-
-// GeneratePreview generates preview thumnail for given media.
-func GeneratePreview(ctx context.Context, src, dst string) error {
-	dur, err := GetDuration(src)
+	var stderr bytes.Buffer
+	ffmpeg.Stderr = &stderr
+	stdout, err := ffmpeg.StdoutPipe()
 	if err != nil {
-		return err
+		return "", err
+	}
+	if err := ffmpeg.Start(); err != nil {
+		return "", fmt.Errorf("failed to start ffmpeg: %w", err)
+	}
+
+	d.ppm.Reset(stdout)
+	for {
+		img, err := d.ppm.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err == nil {
+			err = fn(img)
+		}
+		if err != nil {
+			cancel()
+			_ = ffmpeg.Wait()
+			return "", err
+		}
+	}
+
+	if err := ffmpeg.Wait(); err != nil {
+		msg := strings.TrimSpace(stderr.String())
+		if i := strings.LastIndexByte(msg, '\n'); i >= 0 {
+			msg = msg[i+1:]
+		}
+		if msg != "" {
+			return "", fmt.Errorf("failed to decode with ffmpeg: %w: %s", err, msg)
+		}
+		return "", fmt.Errorf("failed to decode with ffmpeg: %w", err)
 	}
-	seek := fmt.Sprintf("%.2f", dur*0.10) // 10%
-
-	cmd := exec.CommandContext(
-		ctx, "ffmpeg",
-		"-ss", seek,
-		"-i", src,
-		"-vframes", "1",
-		"-y",
-		dst,
-	)
-	return cmd.Run()
+	return stderr.String(), nil
 }
@@ -0,0 +1,67 @@
+package ffmpeg
+
+import (
+	"math"
+	"testing"
+)
+
+func TestFrameTime(t *testing.T) {
+	testdata := []struct {
+		name string
+		log  string
+		want float64
+	}{
+		{
+			"showinfo",
+			"[Parsed_showinfo_0 @ 0x5581] n:   0 pts:  96096 pts_time:4.004   duration:  1001\n",
+			4.004,
+		},
+		{
+			"first frame",
+			"[Parsed_showinfo_0 @ 0x5581] n:   0 pts: 0 pts_time:0 \n" +
+				"[Parsed_showinfo_0 @ 0x5581] n:   1 pts: 1 pts_time:0.04 \n",
+			0,
+		},
+		{"no frame", "Output file is empty, nothing was encoded\n", math.NaN()},
+	}
+
+	for _, test := range testdata {
+		t.Run(test.name, func(t *testing.T) {
+			got := frameTime(test.log)
+			if got != test.want && !(math.IsNaN(got) && math.IsNaN(test.want)) {
+				t.Errorf("frameTime = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestCollapse(t *testing.T) {
+	testdata := []struct {
+		name    string
+		samples []Sample
+		time    float64
+		weight  float64
+		n       int
+	}{
+		{"frame at the sample", []Sample{{1, 0.5}, {2, 0.5}}, 1, 0.5, 1},
+		{"frame before the next sample", []Sample{{1, 0.5}, {2, 0.5}}, 1.5, 0.5, 1},
+		{
+			"samples collapsing onto a frame",
+			[]Sample{{0.1, 0.25}, {0.2, 0.25}, {0.3, 0.25}, {2, 0.25}},
+			0.5, 0.75, 3,
+		},
+		{"frame at the next sample", []Sample{{1, 0.5}, {2, 0.5}}, 2, 1, 2},
+		{"every sample after the last frame", []Sample{{8, 0.5}, {9, 0.5}}, 10, 1, 2},
+		{"unknown frame time", []Sample{{1, 0.5}, {2, 0.5}}, math.NaN(), 0.5, 1},
+		{"last sample", []Sample{{3, 0.2}}, 3, 0.2, 1},
+	}
+
+	for _, test := range testdata {
+		t.Run(test.name, func(t *testing.T) {
+			weight, n := collapse(test.samples, test.time)
+			if math.Abs(weight-test.weight) > 1e-9 || n != test.n {
+				t.Errorf("collapse = %v, %d, want %v, %d", weight, n, test.weight, test.n)
+			}
+		})
+	}
+}
@@ -0,0 +1,237 @@
+package ffmpeg
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Nadim147c/rong/v5/internal/config/enums"
+)
+
+// ErrInvalidPreview means preview options can't be used together or have an
+// invalid value.
+var ErrInvalidPreview = errors.New("invalid preview")
+
+// previewFPS is the frame rate of animated previews.
+const previewFPS = 10
+
+// Preview configures generated previews.
+type Preview struct {
+	Format enums.PreviewFormat
+	Mode   enums.PreviewMode
+	// Seek is the time to preview as a percentage of the duration (e.g.
+	// "10%") or a timestamp (e.g. "90", "1m30s" or "01:30").
+	Seek string
+	// MaxSize is the maximum width and height. Zero keeps the size.
+	MaxSize int
+	// Quality is the quality of lossy formats from 1 to 100.
+	Quality float64
+	// Duration is the length in seconds of animated previews.
+	Duration float64
+	// Columns and Rows are the number of frames of contact sheets.
+	Columns, Rows int
+}
+
+// ParseGrid parses a grid of contact sheets like "3x3".
+func ParseGrid(s string) (columns, rows int, err error) {
+	c, r, ok := strings.Cut(strings.ToLower(strings.TrimSpace(s)), "x")
+	columns, err1 := strconv.Atoi(c)
+	rows, err2 := strconv.Atoi(r)
+	if !ok || err1 != nil || err2 != nil || columns <= 0 || rows <= 0 {
+		return 0, 0, fmt.Errorf("%w: grid %q must be like 3x3", ErrInvalidPreview, s)
+	}
+	return columns, rows, nil
+}
+
+// seekTime returns the time in seconds of seek in media of duration.
+func seekTime(seek string, duration float64) (float64, error) {
+	seek = strings.TrimSpace(seek)
+	if seek == "" {
+		return 0, nil
+	}
+	if p, ok := strings.CutSuffix(seek, "%"); ok {
+		f, err := strconv.ParseFloat(p, 64)
+		if err != nil || f < 0 || f > 100 {
+			return 0, fmt.Errorf("%w: seek %q", ErrInvalidPreview, seek)
+		}
+		return duration * f / 100, nil
+	}
+	if f, err := strconv.ParseFloat(seek, 64); err == nil && f >= 0 {
+		return f, nil
+	}
+	if d, err := time.ParseDuration(seek); err == nil && d >= 0 {
+		return d.Seconds(), nil
+	}
+	if strings.Count(seek, ":") == 1 {
+		seek = "00:" + seek
+	}
+	if strings.Count(seek, ":") == 2 {
+		if t := parseSeconds(seek); t > 0 {
+			return t, nil
+		}
+	}
+	return 0, fmt.Errorf("%w: seek %q must be a percentage or timestamp", ErrInvalidPreview, seek)
+}
+
+// animated reports whether the preview is a clip.
+func (p Preview) animated() bool {
+	return p.Mode == enums.PreviewModeAnimated || p.Format == enums.PreviewFormatWebm
+}
+
+// scale returns a scale filter fitting frames into size.
+func scale(size int) string {
+	return fmt.Sprintf(
+		"scale=w='min(iw,%[1]d)':h='min(ih,%[1]d)':force_original_aspect_ratio=decrease",
+		size,
+	)
+}
+
+// encoder returns output arguments of the preview format.
+func (p Preview) encoder() []string {
+	q := p.Quality
+	switch p.Format {
+	case enums.PreviewFormatJpg, enums.PreviewFormatJpeg:
+		// 2 is the best and 31 the worst quality of mjpeg.
+		return []string{"-q:v", strconv.Itoa(2 + int((100-q)*29/100))}
+	case enums.PreviewFormatWebp:
+		args := []string{"-c:v", "libwebp", "-quality", strconv.Itoa(int(q))}
+		if p.animated() {
+			args = append(args, "-loop", "0")
+		}
+		return args
+	case enums.PreviewFormatWebm:
+		return []string{"-c:v", "libvpx-vp9", "-b:v", "0", "-crf", strconv.Itoa(4 + int((100-q)*59/100))}
+	case enums.PreviewFormatGif:
+		if p.animated() {
+			return []string{"-loop", "0"}
+		}
+	}
+	return nil
+}
+
+// validate reports whether the mode of p can be encoded in its format.
+// Animated previews need an animated format, and contact sheets are still
+// images, which webm can't hold.
+func (p Preview) validate() error {
+	if p.Mode == enums.PreviewModeSheet && p.Format == enums.PreviewFormatWebm {
+		return fmt.Errorf("%w: contact sheets need the jpg, png, webp or gif format", ErrInvalidPreview)
+	}
+	switch p.Format {
+	case enums.PreviewFormatGif, enums.PreviewFormatWebp, enums.PreviewFormatWebm:
+	default:
+		if p.animated() {
+			return fmt.Errorf("%w: animated previews need the webp, gif or webm format", ErrInvalidPreview)
+		}
+	}
+	return nil
+}
+
+// GeneratePreview generates a preview of src at dst. Still images are
+// previewed by their only frame. Animated media are previewed by a frame at
+// the seek time, a short looping clip from it, or a contact sheet of frames
+// evenly spread over the media. The preview is written to a temporary file
+// next to dst that replaces it once complete, so an interrupted run never
+// leaves a partial preview at dst.
+func GeneratePreview(ctx context.Context, src, dst string, p Preview) error {
+	if err := p.validate(); err != nil {
+		return err
+	}
+
+	info, err := Probe(ctx, src)
+	if err != nil {
+		return err
+	}
+
+	seek, err := seekTime(p.Seek, info.Duration)
+	if err != nil {
+		return err
+	}
+	if info.Duration > 0 {
+		// Keep the previewed frames inside of the media.
+		length := 0.1
+		if p.animated() {
+			length = p.Duration
+		}
+		seek = max(0, min(seek, info.Duration-length))
+	}
+
+	var args, filters []string
+	switch {
+	case !info.Animated:
+		args = append(args, "-i", src, "-vframes", "1")
+		if p.MaxSize > 0 {
+			filters = append(filters, scale(p.MaxSize))
+		}
+	case p.Mode == enums.PreviewModeSheet:
+		n := p.Columns * p.Rows
+		duration := info.Duration
+		if duration == 0 {
+			duration = float64(n)
+		}
+		args = append(args, "-i", src, "-vframes", "1")
+		filters = append(filters, fmt.Sprintf("fps=%.8f", float64(n)/duration))
+		if p.MaxSize > 0 {
+			filters = append(filters, scale(max(1, p.MaxSize/max(p.Columns, p.Rows))))
+		}
+		filters = append(filters, fmt.Sprintf("tile=%dx%d", p.Columns, p.Rows))
+	case p.animated():
+		args = append(args,
+			"-ss", fmt.Sprintf("%.2f", seek),
+			"-t", fmt.Sprintf("%.2f", p.Duration),
+			"-i", src, "-an",
+		)
+		filters = append(filters, fmt.Sprintf("fps=%d", previewFPS))
+		if p.MaxSize > 0 {
+			filters = append(filters, scale(p.MaxSize))
+		}
+	default:
+		args = append(args, "-ss", fmt.Sprintf("%.2f", seek), "-i", src, "-vframes", "1")
+		if p.MaxSize > 0 {
+			filters = append(filters, scale(p.MaxSize))
+		}
+	}
+
+	filter := strings.Join(filters, ",")
+	if p.Format == enums.PreviewFormatGif && info.Animated && p.Mode == enums.PreviewModeAnimated {
+		// A palette of the clip itself keeps gif previews from banding.
+		filter += ",split[a][b];[a]palettegen[p];[b][p]paletteuse"
+		filter = strings.TrimPrefix(filter, ",")
+	}
+	if filter != "" {
+		args = append(args, "-vf", filter)
+	}
+	args = append(args, p.encoder()...)
+
+	cmd, err := command(ctx, "ffmpeg", args...)
+	if err != nil {
+		return err
+	}
+
+	// The temporary file keeps the extension ffmpeg picks the muxer by.
+	tmp, err := os.CreateTemp(filepath.Dir(dst), ".preview-*"+filepath.Ext(dst))
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0o640); err != nil {
+		return err
+	}
+
+	cmd.Args = append(cmd.Args, "-y", tmp.Name())
+	if out, err := cmd.CombinedOutput(); err != nil {
+		msg := strings.TrimSpace(string(out))
+		if i := strings.LastIndexByte(msg, '\n'); i >= 0 {
+			msg = msg[i+1:]
+		}
+		return fmt.Errorf("failed to generate preview: %w: %s", err, msg)
+	}
+	return os.Rename(tmp.Name(), dst)
+}
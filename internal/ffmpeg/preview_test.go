@@ -0,0 +1,60 @@
+package ffmpeg
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/Nadim147c/rong/v5/internal/config/enums"
+)
+
+func TestPreviewValidate(t *testing.T) {
+	testdata := []struct {
+		name   string
+		format enums.PreviewFormat
+		mode   enums.PreviewMode
+		err    error
+	}{
+		{"jpg frame", enums.PreviewFormatJpg, enums.PreviewModeFrame, nil},
+		{"webm frame is animated", enums.PreviewFormatWebm, enums.PreviewModeFrame, nil},
+		{"gif clip", enums.PreviewFormatGif, enums.PreviewModeAnimated, nil},
+		{"png clip", enums.PreviewFormatPng, enums.PreviewModeAnimated, ErrInvalidPreview},
+		{"png sheet", enums.PreviewFormatPng, enums.PreviewModeSheet, nil},
+		{"webp sheet", enums.PreviewFormatWebp, enums.PreviewModeSheet, nil},
+		{"gif sheet", enums.PreviewFormatGif, enums.PreviewModeSheet, nil},
+		{"webm sheet", enums.PreviewFormatWebm, enums.PreviewModeSheet, ErrInvalidPreview},
+	}
+
+	for _, test := range testdata {
+		t.Run(test.name, func(t *testing.T) {
+			err := Preview{Format: test.format, Mode: test.mode}.validate()
+			if !errors.Is(err, test.err) {
+				t.Errorf("validate = %v, want %v", err, test.err)
+			}
+		})
+	}
+}
+
+func TestPreviewEncoderLoops(t *testing.T) {
+	testdata := []struct {
+		name   string
+		format enums.PreviewFormat
+		mode   enums.PreviewMode
+		loops  bool
+	}{
+		{"gif clip", enums.PreviewFormatGif, enums.PreviewModeAnimated, true},
+		{"webp clip", enums.PreviewFormatWebp, enums.PreviewModeAnimated, true},
+		{"gif sheet", enums.PreviewFormatGif, enums.PreviewModeSheet, false},
+		{"webp sheet", enums.PreviewFormatWebp, enums.PreviewModeSheet, false},
+		{"webp frame", enums.PreviewFormatWebp, enums.PreviewModeFrame, false},
+	}
+
+	for _, test := range testdata {
+		t.Run(test.name, func(t *testing.T) {
+			args := Preview{Format: test.format, Mode: test.mode, Quality: 80}.encoder()
+			if loops := slices.Contains(args, "-loop"); loops != test.loops {
+				t.Errorf("encoder = %q, loops = %v, want %v", args, loops, test.loops)
+			}
+		})
+	}
+}
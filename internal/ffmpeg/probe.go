@@ -0,0 +1,205 @@
+package ffmpeg
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Stream is a stream of a probed media file.
+type Stream struct {
+	Index int
+	// Type is the codec type, like video, audio or subtitle.
+	Type  string
+	Codec string
+	// Width and Height are the stored size of video frames.
+	Width, Height int
+	// Rotation is the clockwise rotation of frames in degrees when displayed.
+	Rotation int
+	// Frames is the number of frames, or zero when unknown.
+	Frames int
+	// FrameRate is the average number of frames per second.
+	FrameRate float64
+	// Duration is the duration in seconds, or zero when unknown.
+	Duration float64
+}
+
+// Info describes a probed media file. Its fields are of the first video stream
+// and the container.
+type Info struct {
+	Format  string
+	Streams []Stream
+	// Width and Height are the displayed size of frames, after rotation.
+	Width, Height int
+	Rotation      int
+	// Frames is the number of frames, or zero when unknown.
+	Frames int
+	// Duration is the duration in seconds, or zero when unknown.
+	Duration float64
+	// Animated reports whether the media has more than one frame.
+	Animated bool
+}
+
+// Still reports whether the media is a single image.
+func (i Info) Still() bool {
+	return !i.Animated
+}
+
+// probeOutput is the JSON output of ffprobe.
+type probeOutput struct {
+	Format struct {
+		Name     string `json:"format_name"`
+		Duration string `json:"duration"`
+	} `json:"format"`
+	Streams []struct {
+		Index        int               `json:"index"`
+		CodecType    string            `json:"codec_type"`
+		CodecName    string            `json:"codec_name"`
+		Width        int               `json:"width"`
+		Height       int               `json:"height"`
+		NbFrames     string            `json:"nb_frames"`
+		AvgFrameRate string            `json:"avg_frame_rate"`
+		RFrameRate   string            `json:"r_frame_rate"`
+		Duration     string            `json:"duration"`
+		Tags         map[string]string `json:"tags"`
+		SideData     []struct {
+			Rotation float64 `json:"rotation"`
+		} `json:"side_data_list"`
+		Disposition struct {
+			AttachedPic int `json:"attached_pic"`
+		} `json:"disposition"`
+	} `json:"streams"`
+}
+
+// Probe runs ffprobe and returns information about the media file at path.
+// The duration falls back to stream durations, duration tags and frame counts
+// when the container doesn't have one.
+func Probe(ctx context.Context, path string) (Info, error) {
+	ffprobe, err := command(ctx, "ffprobe",
+		"-v", "error",
+		"-print_format", "json",
+		"-show_format",
+		"-show_streams",
+		path,
+	)
+	if err != nil {
+		return Info{}, err
+	}
+
+	out, err := ffprobe.Output()
+	if err != nil {
+		return Info{}, fmt.Errorf("failed to probe %s: %w", path, err)
+	}
+
+	var probed probeOutput
+	if err := json.Unmarshal(out, &probed); err != nil {
+		return Info{}, fmt.Errorf("failed to parse ffprobe output: %w", err)
+	}
+
+	info := Info{Format: probed.Format.Name}
+	var video *Stream
+	for _, s := range probed.Streams {
+		stream := Stream{
+			Index:     s.Index,
+			Type:      s.CodecType,
+			Codec:     s.CodecName,
+			Width:     s.Width,
+			Height:    s.Height,
+			FrameRate: parseRate(s.AvgFrameRate),
+			Duration:  parseSeconds(s.Duration),
+		}
+		if stream.FrameRate == 0 {
+			stream.FrameRate = parseRate(s.RFrameRate)
+		}
+		stream.Frames, _ = strconv.Atoi(s.NbFrames)
+
+		if stream.Duration == 0 {
+			stream.Duration = parseSeconds(s.Tags["DURATION"])
+		}
+		if stream.Duration == 0 && stream.Frames > 1 && stream.FrameRate > 0 {
+			stream.Duration = float64(stream.Frames) / stream.FrameRate
+		}
+
+		if rotate, err := strconv.Atoi(s.Tags["rotate"]); err == nil {
+			stream.Rotation = rotate
+		}
+		for _, side := range s.SideData {
+			if side.Rotation != 0 {
+				// Display matrices rotate counterclockwise.
+				stream.Rotation = -int(math.Round(side.Rotation))
+			}
+		}
+		stream.Rotation = ((stream.Rotation % 360) + 360) % 360
+
+		info.Streams = append(info.Streams, stream)
+		if video == nil && stream.Type == "video" && s.Disposition.AttachedPic == 0 {
+			video = &info.Streams[len(info.Streams)-1]
+		}
+	}
+
+	info.Duration = parseSeconds(probed.Format.Duration)
+	if video != nil {
+		info.Width, info.Height = video.Width, video.Height
+		info.Rotation = video.Rotation
+		if info.Rotation == 90 || info.Rotation == 270 {
+			info.Width, info.Height = info.Height, info.Width
+		}
+		info.Frames = video.Frames
+		if info.Duration == 0 {
+			info.Duration = video.Duration
+		}
+		if info.Frames == 0 && info.Duration > 0 && video.FrameRate > 0 {
+			info.Frames = int(math.Round(info.Duration * video.FrameRate))
+		}
+	}
+
+	// Still images don't report a duration, or have a single frame.
+	info.Animated = info.Frames > 1 || (info.Frames == 0 && info.Duration > 0)
+	return info, nil
+}
+
+// parseRate parses a frame rate like "30000/1001".
+func parseRate(s string) float64 {
+	num, den, ok := strings.Cut(s, "/")
+	n, err := strconv.ParseFloat(num, 64)
+	if err != nil {
+		return 0
+	}
+	if !ok {
+		return n
+	}
+	d, err := strconv.ParseFloat(den, 64)
+	if err != nil || d == 0 {
+		return 0
+	}
+	return n / d
+}
+
+// parseSeconds parses durations in seconds like "12.5" or durations like
+// "00:01:02.500000000" of duration tags.
+func parseSeconds(s string) float64 {
+	s = strings.TrimSpace(s)
+	if s == "" || s == "N/A" {
+		return 0
+	}
+	if f, err := strconv.ParseFloat(s, 64); err == nil {
+		return max(f, 0)
+	}
+
+	parts := strings.Split(s, ":")
+	if len(parts) != 3 {
+		return 0
+	}
+	h, err1 := strconv.Atoi(parts[0])
+	m, err2 := strconv.Atoi(parts[1])
+	sec, err3 := strconv.ParseFloat(parts[2], 64)
+	if err1 != nil || err2 != nil || err3 != nil {
+		return 0
+	}
+	d := time.Duration(h)*time.Hour + time.Duration(m)*time.Minute
+	return d.Seconds() + sec
+}
@@ -0,0 +1,243 @@
+package ffmpeg
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/Nadim147c/rong/v5/internal/config/enums"
+)
+
+// ErrUnknownDuration means the frames of a video can't be spread over it,
+// because its duration is unknown and no duration to sample is set.
+var ErrUnknownDuration = errors.New("unknown video duration")
+
+// Sampling configures how frames of videos are picked.
+type Sampling struct {
+	Strategy enums.Sampling
+	// Frames is the maximum number of frames.
+	Frames int
+	// Offset is the time in seconds to start sampling from.
+	Offset float64
+	// Duration is the length in seconds of the sampled window of the window
+	// strategy.
+	Duration float64
+	// Threshold is the minimum scene score of a frame starting a new scene.
+	Threshold float64
+}
+
+// Sample is a time of a video to decode a frame at and the share of the video
+// the frame stands for.
+type Sample struct {
+	Time   float64
+	Weight float64
+}
+
+// Samples returns the frames to decode from the video at path described by
+// info.
+//
+//   - window: Frames evenly spread over Duration seconds from Offset.
+//   - uniform: Frames evenly spread from Offset to the end.
+//   - scene: the first frame of every scene after Offset, weighted by how long
+//     the scene lasts. Only the longest scenes are kept.
+//   - keyframes: keyframes after Offset, weighted like scenes.
+//
+// Videos without a known duration are sampled over the window of the window
+// strategy instead of the whole video. Without a window either, the window and
+// uniform strategies return ErrUnknownDuration.
+func Samples(ctx context.Context, path string, info Info, s Sampling) ([]Sample, error) {
+	duration := info.Duration
+	start := s.Offset
+	if duration > 0 {
+		start = min(start, duration)
+	}
+	n := max(s.Frames, 1)
+
+	window := duration
+	if s.Duration > 0 && (duration == 0 || start+s.Duration < duration) {
+		window = start + s.Duration
+	}
+
+	// Without an end, every frame would be at start.
+	unknown := duration == 0 && window <= start
+
+	switch s.Strategy {
+	case enums.SamplingUniform:
+		if unknown {
+			return nil, fmt.Errorf("%w: set a duration to sample", ErrUnknownDuration)
+		}
+		if duration == 0 {
+			return uniform(start, window, n), nil
+		}
+		return uniform(start, duration, n), nil
+	case enums.SamplingScene:
+		times, err := scenes(ctx, path, start, s.Threshold)
+		if err != nil {
+			return nil, fmt.Errorf("failed to detect scenes: %w", err)
+		}
+		times = append([]float64{start}, times...)
+		return intervals(times, end(times, duration), n), nil
+	case enums.SamplingKeyframes:
+		times, err := keyframes(ctx, path, start)
+		if err != nil {
+			return nil, fmt.Errorf("failed to find keyframes: %w", err)
+		}
+		if len(times) == 0 {
+			times = []float64{start}
+		}
+		return intervals(times, end(times, duration), n), nil
+	default:
+		if unknown {
+			return nil, fmt.Errorf("%w: set a duration to sample", ErrUnknownDuration)
+		}
+		return uniform(start, window, n), nil
+	}
+}
+
+// end returns the duration, or a guess after the last of the sorted times when
+// the duration is unknown.
+func end(times []float64, duration float64) float64 {
+	if duration > 0 {
+		return duration
+	}
+	last := times[len(times)-1]
+	if len(times) == 1 {
+		return last + 1
+	}
+	return last + (last-times[0])/float64(len(times)-1)
+}
+
+// uniform returns n samples in the middle of n equal parts of start to end.
+func uniform(start, end float64, n int) []Sample {
+	step := (end - start) / float64(n)
+	samples := make([]Sample, n)
+	for i := range samples {
+		samples[i] = Sample{start + (float64(i)+0.5)*step, 1 / float64(n)}
+	}
+	return samples
+}
+
+// intervals returns a sample for each of the sorted times, weighted by the
+// time until the next one or end. Only the n longest intervals are kept.
+func intervals(times []float64, end float64, n int) []Sample {
+	var samples []Sample
+	var total float64
+	for i, t := range times {
+		next := end
+		if i+1 < len(times) {
+			next = times[i+1]
+		}
+		if next > t {
+			samples = append(samples, Sample{t, next - t})
+			total += next - t
+		}
+	}
+	if len(samples) == 0 {
+		return []Sample{{min(times[0], end), 1}}
+	}
+
+	if len(samples) > n {
+		slices.SortStableFunc(samples, func(a, b Sample) int {
+			return -cmpFloat(a.Weight, b.Weight)
+		})
+		samples = samples[:n]
+		slices.SortFunc(samples, func(a, b Sample) int { return cmpFloat(a.Time, b.Time) })
+
+		total = 0
+		for _, s := range samples {
+			total += s.Weight
+		}
+	}
+
+	for i := range samples {
+		samples[i].Weight /= total
+	}
+	return samples
+}
+
+func cmpFloat(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// ptsTime matches the timestamp of frames printed by the showinfo filter.
+var ptsTime = regexp.MustCompile(`pts_time:\s*([0-9.]+)`)
+
+// scenes returns times of frames after start with a scene score above
+// threshold.
+func scenes(ctx context.Context, path string, start, threshold float64) ([]float64, error) {
+	ffmpeg, err := command(ctx, "ffmpeg",
+		"-hide_banner",
+		"-ss", fmt.Sprintf("%.5f", start),
+		"-i", path,
+		"-an",
+		"-vf", fmt.Sprintf("select='gt(scene,%g)',showinfo", threshold),
+		"-f", "null",
+		"-",
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := ffmpeg.CombinedOutput()
+	if err != nil {
+		return nil, err
+	}
+
+	var times []float64
+	lines := bufio.NewScanner(strings.NewReader(string(out)))
+	for lines.Scan() {
+		line := lines.Text()
+		if !strings.Contains(line, "Parsed_showinfo") {
+			continue
+		}
+		if m := ptsTime.FindStringSubmatch(line); m != nil {
+			if t, err := strconv.ParseFloat(m[1], 64); err == nil && t > 0 {
+				// Input seeking makes timestamps start at zero.
+				times = append(times, start+t)
+			}
+		}
+	}
+	return times, nil
+}
+
+// keyframes returns times of keyframes at or after start.
+func keyframes(ctx context.Context, path string, start float64) ([]float64, error) {
+	ffprobe, err := command(ctx, "ffprobe",
+		"-v", "error",
+		"-select_streams", "v:0",
+		"-skip_frame", "nokey",
+		"-show_entries", "frame=pts_time",
+		"-of", "csv=p=0",
+		"-read_intervals", fmt.Sprintf("%.5f%%", start),
+		path,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := ffprobe.Output()
+	if err != nil {
+		return nil, err
+	}
+
+	var times []float64
+	for field := range strings.FieldsSeq(string(out)) {
+		t, err := strconv.ParseFloat(strings.Trim(field, ","), 64)
+		if err == nil && t >= start {
+			times = append(times, t)
+		}
+	}
+	slices.Sort(times)
+	return slices.Compact(times), nil
+}
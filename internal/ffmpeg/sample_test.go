@@ -0,0 +1,137 @@
+package ffmpeg
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/Nadim147c/rong/v5/internal/config/enums"
+)
+
+// equal reports whether samples match want within rounding errors.
+func equal(samples, want []Sample) bool {
+	if len(samples) != len(want) {
+		return false
+	}
+	for i := range samples {
+		if math.Abs(samples[i].Time-want[i].Time) > 1e-9 ||
+			math.Abs(samples[i].Weight-want[i].Weight) > 1e-9 {
+			return false
+		}
+	}
+	return true
+}
+
+func TestUniform(t *testing.T) {
+	testdata := []struct {
+		name       string
+		start, end float64
+		n          int
+		want       []Sample
+	}{
+		{"single", 0, 10, 1, []Sample{{5, 1}}},
+		{"several", 0, 10, 5, []Sample{{1, 0.2}, {3, 0.2}, {5, 0.2}, {7, 0.2}, {9, 0.2}}},
+		{"offset", 10, 20, 2, []Sample{{12.5, 0.5}, {17.5, 0.5}}},
+	}
+
+	for _, test := range testdata {
+		t.Run(test.name, func(t *testing.T) {
+			if got := uniform(test.start, test.end, test.n); !equal(got, test.want) {
+				t.Errorf("uniform(%v, %v, %d) = %v, want %v", test.start, test.end, test.n, got, test.want)
+			}
+		})
+	}
+}
+
+func TestIntervals(t *testing.T) {
+	testdata := []struct {
+		name  string
+		times []float64
+		end   float64
+		n     int
+		want  []Sample
+	}{
+		{"single", []float64{0}, 10, 5, []Sample{{0, 1}}},
+		{"weighted by length", []float64{0, 2, 8}, 10, 5, []Sample{{0, 0.2}, {2, 0.6}, {8, 0.2}}},
+		{"longest kept in order", []float64{0, 1, 5, 6}, 10, 2, []Sample{{1, 0.5}, {6, 0.5}}},
+		{"empty intervals", []float64{0, 0, 5}, 10, 5, []Sample{{0, 0.5}, {5, 0.5}}},
+		{"time at end", []float64{10}, 10, 5, []Sample{{10, 1}}},
+	}
+
+	for _, test := range testdata {
+		t.Run(test.name, func(t *testing.T) {
+			if got := intervals(test.times, test.end, test.n); !equal(got, test.want) {
+				t.Errorf("intervals(%v, %v, %d) = %v, want %v", test.times, test.end, test.n, got, test.want)
+			}
+		})
+	}
+}
+
+func TestSamples(t *testing.T) {
+	testdata := []struct {
+		name     string
+		duration float64
+		sampling Sampling
+		want     []Sample
+		err      error
+	}{
+		{
+			"window", 100,
+			Sampling{Strategy: enums.SamplingWindow, Frames: 2, Duration: 10},
+			[]Sample{{2.5, 0.5}, {7.5, 0.5}},
+			nil,
+		},
+		{
+			"window with offset", 100,
+			Sampling{Strategy: enums.SamplingWindow, Frames: 1, Offset: 20, Duration: 10},
+			[]Sample{{25, 1}},
+			nil,
+		},
+		{
+			"window longer than video", 4,
+			Sampling{Strategy: enums.SamplingWindow, Frames: 2, Duration: 10},
+			[]Sample{{1, 0.5}, {3, 0.5}},
+			nil,
+		},
+		{
+			"uniform", 100,
+			Sampling{Strategy: enums.SamplingUniform, Frames: 2, Duration: 10},
+			[]Sample{{25, 0.5}, {75, 0.5}},
+			nil,
+		},
+		{
+			"uniform of unknown duration", 0,
+			Sampling{Strategy: enums.SamplingUniform, Frames: 2, Duration: 10},
+			[]Sample{{2.5, 0.5}, {7.5, 0.5}},
+			nil,
+		},
+		{
+			"uniform without any duration", 0,
+			Sampling{Strategy: enums.SamplingUniform, Frames: 2},
+			nil, ErrUnknownDuration,
+		},
+		{
+			"window without any duration", 0,
+			Sampling{Strategy: enums.SamplingWindow, Frames: 2, Offset: 5},
+			nil, ErrUnknownDuration,
+		},
+	}
+
+	for _, test := range testdata {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := Samples(t.Context(), "video.mkv", Info{Duration: test.duration}, test.sampling)
+			if test.err != nil {
+				if !errors.Is(err, test.err) {
+					t.Errorf("Samples error = %v, want %v", err, test.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !equal(got, test.want) {
+				t.Errorf("Samples = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
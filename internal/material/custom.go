@@ -1,6 +1,7 @@
 package material
 
 import (
+	"context"
 	"fmt"
 	"log/slog"
 	"regexp"
@@ -21,13 +22,13 @@ type CustomColor struct {
 var nameRe = regexp.MustCompile("^[A-Za-z0-9][A-Za-z0-9_]+$")
 
 // GenerateCustomColors returns all custom colors.
-func GenerateCustomColors(primary color.ARGB) (map[string]CustomColor, error) {
-	defined := config.MaterialCustomColors.Value()
+func GenerateCustomColors(ctx context.Context, primary color.ARGB) (map[string]CustomColor, error) {
+	defined := config.MaterialCustomColors.Value(ctx)
 	if len(defined) == 0 {
 		return map[string]CustomColor{}, nil
 	}
-	dark := config.Dark.Value()
-	blend := config.MaterialCustomBlend.Value()
+	dark := config.Dark.Value(ctx)
+	blend := config.MaterialCustomBlend.Value(ctx)
 
 	m := make(map[string]CustomColor, len(defined))
 	for name, col := range defined {
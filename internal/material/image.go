@@ -3,6 +3,8 @@ package material
 import (
 	"context"
 	"image"
+	stdcolor "image/color"
+	"math"
 
 	"github.com/Nadim147c/material/v3/color"
 	"github.com/Nadim147c/material/v3/dynamic"
@@ -16,28 +18,97 @@ type Config struct {
 	Version   dynamic.Version
 	Dark      bool
 	Constrast float64
+	// SourceRank is the rank of the scored color used as source color,
+	// starting from 1.
+	SourceRank int
 }
 
-func GetConfig() Config {
+// GetConfig returns the configured generation options of ctx.
+func GetConfig(ctx context.Context) Config {
 	return Config{
-		Variant:   config.MaterialVariant.Value(),
-		Version:   config.MaterialVersion.Value(),
-		Platform:  config.MaterialPlatformt.Value(),
-		Constrast: config.MaterialContrast.Value(),
-		Dark:      config.Dark.Value(),
+		Variant:   config.MaterialVariant.Value(ctx),
+		Version:   config.MaterialVersion.Value(ctx),
+		Platform:  config.MaterialPlatformt.Value(ctx),
+		Constrast: config.MaterialContrast.Value(ctx),
+		Dark:      config.Dark.Value(ctx),
+
+		SourceRank: config.SourceRank.Value(ctx),
 	}
 }
 
-// GetPixelsFromImage returns pixels from image.Imaget interface.
-func GetPixelsFromImage(img image.Image) []color.ARGB {
+// GetPixelsFromImage returns pixels from image.Imaget interface. Images larger
+// than maxPixels are sampled every few rows and columns, so the result is the
+// same for the same image. A maxPixels of zero or less returns every pixel.
+func GetPixelsFromImage(img image.Image, maxPixels int) []color.ARGB {
 	bounds := img.Bounds()
-	pixels := make([]color.ARGB, 0, bounds.Dx()*bounds.Dy())
+	w, h := bounds.Dx(), bounds.Dy()
+	if w <= 0 || h <= 0 {
+		return nil
+	}
+
+	// Same stride for rows and columns keeps the aspect of the sampled grid.
+	step := 1
+	if maxPixels > 0 && w*h > maxPixels {
+		step = max(1, int(math.Sqrt(float64(w*h)/float64(maxPixels))))
+		for ((w+step-1)/step)*((h+step-1)/step) > maxPixels {
+			step++
+		}
+	}
+	pixels := make([]color.ARGB, 0, ((w+step-1)/step)*((h+step-1)/step))
 
-	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
-		for x := bounds.Min.X; x < bounds.Max.X; x++ {
-			c := img.At(x, y)
-			argb := color.ARGBFromInterface(c)
-			pixels = append(pixels, argb)
+	switch img := img.(type) {
+	case *image.RGBA:
+		for y := bounds.Min.Y; y < bounds.Max.Y; y += step {
+			row := img.Pix[img.PixOffset(bounds.Min.X, y):]
+			for x := 0; x < w; x += step {
+				p := row[x*4 : x*4+4 : x*4+4]
+				if p[3] == 0xff {
+					pixels = append(pixels, color.ARGBFromRGB(p[0], p[1], p[2]))
+					continue
+				}
+				c := stdcolor.RGBA{R: p[0], G: p[1], B: p[2], A: p[3]}
+				pixels = append(pixels, color.ARGBFromInterface(c))
+			}
+		}
+	case *image.NRGBA:
+		for y := bounds.Min.Y; y < bounds.Max.Y; y += step {
+			row := img.Pix[img.PixOffset(bounds.Min.X, y):]
+			for x := 0; x < w; x += step {
+				p := row[x*4 : x*4+4 : x*4+4]
+				if p[3] == 0xff {
+					pixels = append(pixels, color.ARGBFromRGB(p[0], p[1], p[2]))
+					continue
+				}
+				c := stdcolor.NRGBA{R: p[0], G: p[1], B: p[2], A: p[3]}
+				pixels = append(pixels, color.ARGBFromInterface(c))
+			}
+		}
+	case *image.YCbCr:
+		for y := bounds.Min.Y; y < bounds.Max.Y; y += step {
+			for x := bounds.Min.X; x < bounds.Max.X; x += step {
+				yi, ci := img.YOffset(x, y), img.COffset(x, y)
+				r, g, b, _ := stdcolor.YCbCr{Y: img.Y[yi], Cb: img.Cb[ci], Cr: img.Cr[ci]}.RGBA()
+				pixels = append(pixels, color.ARGBFromRGB(uint8(r>>8), uint8(g>>8), uint8(b>>8)))
+			}
+		}
+	case *image.Paletted:
+		palette := make([]color.ARGB, len(img.Palette))
+		for i, c := range img.Palette {
+			palette[i] = color.ARGBFromInterface(c)
+		}
+		for y := bounds.Min.Y; y < bounds.Max.Y; y += step {
+			row := img.Pix[img.PixOffset(bounds.Min.X, y):]
+			for x := 0; x < w; x += step {
+				if i := int(row[x]); i < len(palette) {
+					pixels = append(pixels, palette[i])
+				}
+			}
+		}
+	default:
+		for y := bounds.Min.Y; y < bounds.Max.Y; y += step {
+			for x := bounds.Min.X; x < bounds.Max.X; x += step {
+				pixels = append(pixels, color.ARGBFromInterface(img.At(x, y)))
+			}
 		}
 	}
 
@@ -51,6 +122,6 @@ func GenerateFromImage(
 	cfg Config,
 	source color.ARGB,
 ) (Colors, error) {
-	pixels := GetPixelsFromImage(img)
+	pixels := GetPixelsFromImage(img, config.QuantizeMaxPixels.Value(ctx))
 	return GenerateFromPixels(ctx, pixels, cfg, source)
 }
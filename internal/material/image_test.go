@@ -0,0 +1,118 @@
+package material
+
+import (
+	"image"
+	stdcolor "image/color"
+	"slices"
+	"testing"
+
+	"github.com/Nadim147c/material/v3/color"
+)
+
+// opaque hides the concrete type of an image to use the generic path.
+type opaque struct{ image.Image }
+
+// pixelsAt returns pixels the way GetPixelsFromImage did before fast paths.
+func pixelsAt(img image.Image) []color.ARGB {
+	bounds := img.Bounds()
+	pixels := make([]color.ARGB, 0, bounds.Dx()*bounds.Dy())
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			pixels = append(pixels, color.ARGBFromInterface(img.At(x, y)))
+		}
+	}
+	return pixels
+}
+
+func testImages(w, h int) map[string]image.Image {
+	rect := image.Rect(0, 0, w, h)
+	rgba := image.NewRGBA(rect)
+	nrgba := image.NewNRGBA(rect)
+	ycbcr := image.NewYCbCr(rect, image.YCbCrSubsampleRatio420)
+	paletted := image.NewPaletted(rect, stdcolor.Palette{
+		stdcolor.RGBA{0xd0, 0x87, 0x70, 0xff},
+		stdcolor.RGBA{0x88, 0xc0, 0xd0, 0xff},
+		stdcolor.RGBA{0x2e, 0x34, 0x40, 0xff},
+	})
+
+	for y := range h {
+		for x := range w {
+			// Wallpapers are mostly opaque, some translucent pixels test the
+			// slow path.
+			c := stdcolor.NRGBA{R: uint8(x), G: uint8(y), B: uint8(x ^ y), A: 0xff}
+			if x%16 == 0 {
+				c.A = 0x80
+			}
+			rgba.Set(x, y, c)
+			nrgba.Set(x, y, c)
+			paletted.SetColorIndex(x, y, uint8((x+y)%3))
+			ycbcr.Y[ycbcr.YOffset(x, y)] = uint8(x + y)
+			ycbcr.Cb[ycbcr.COffset(x, y)] = uint8(x)
+			ycbcr.Cr[ycbcr.COffset(x, y)] = uint8(y)
+		}
+	}
+
+	return map[string]image.Image{
+		"rgba":     rgba,
+		"nrgba":    nrgba,
+		"ycbcr":    ycbcr,
+		"paletted": paletted,
+		// Sub images have a non-zero origin and stride larger than the width.
+		"sub": rgba.SubImage(image.Rect(3, 5, w-2, h-1)),
+	}
+}
+
+func TestGetPixelsFromImage(t *testing.T) {
+	for name, img := range testImages(67, 41) {
+		t.Run(name, func(t *testing.T) {
+			want := pixelsAt(img)
+			if got := GetPixelsFromImage(img, 0); !slices.Equal(got, want) {
+				t.Errorf("fast path differs from image.At")
+			}
+			if got := GetPixelsFromImage(opaque{img}, 0); !slices.Equal(got, want) {
+				t.Errorf("generic path differs from image.At")
+			}
+		})
+	}
+}
+
+func TestGetPixelsFromImageMaxPixels(t *testing.T) {
+	for name, img := range testImages(640, 360) {
+		t.Run(name, func(t *testing.T) {
+			for _, limit := range []int{1, 1000, 50000, 230400} {
+				got := GetPixelsFromImage(img, limit)
+				if len(got) > limit || len(got) == 0 {
+					t.Errorf("got %d pixels with limit %d", len(got), limit)
+				}
+				if again := GetPixelsFromImage(img, limit); !slices.Equal(got, again) {
+					t.Errorf("sampling with limit %d is not deterministic", limit)
+				}
+				if generic := GetPixelsFromImage(opaque{img}, limit); !slices.Equal(got, generic) {
+					t.Errorf("fast path samples differently with limit %d", limit)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkGetPixelsFromImage(b *testing.B) {
+	images := testImages(3840, 2160)
+	for _, name := range []string{"rgba", "nrgba", "ycbcr", "paletted"} {
+		img := images[name]
+		b.Run(name+"/at", func(b *testing.B) {
+			for b.Loop() {
+				pixelsAt(img)
+			}
+		})
+		b.Run(name+"/fast", func(b *testing.B) {
+			for b.Loop() {
+				GetPixelsFromImage(img, 0)
+			}
+		})
+		b.Run(name+"/max-pixels", func(b *testing.B) {
+			for b.Loop() {
+				GetPixelsFromImage(img, 1<<20)
+			}
+		})
+	}
+}
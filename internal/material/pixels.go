@@ -3,11 +3,14 @@ package material
 import (
 	"context"
 	"errors"
+	"fmt"
+	"math"
 
 	"github.com/Nadim147c/material/v3/color"
 	"github.com/Nadim147c/material/v3/dynamic"
 	"github.com/Nadim147c/material/v3/quantizer"
 	"github.com/Nadim147c/material/v3/score"
+	"github.com/Nadim147c/rong/v5/internal/config/enums"
 )
 
 // Quantized is quantized colors.
@@ -16,29 +19,89 @@ type Quantized struct {
 	Wu     []color.ARGB       `json:"wu"`
 }
 
-// Quantize quantizes list of pixels.
-func Quantize(ctx context.Context, pixels []color.ARGB) (Quantized, error) {
-	wu, err := quantizer.QuantizeWuContext(ctx, pixels, 100)
+// Quantize quantizes list of pixels with opts. Wu colors are kept for base16
+// generation and the colors of opts.Method are used for scoring.
+func Quantize(ctx context.Context, pixels []color.ARGB, opts QuantizeOptions) (Quantized, error) {
+	pixels = opts.filter(pixels)
+
+	wu, err := quantizer.QuantizeWuContext(ctx, pixels, max(opts.MaxColors, 1))
 	if err != nil {
 		return Quantized{}, err
 	}
 
-	colors := make([]color.Lab, len(wu))
-	for i, c := range wu {
-		colors[i] = c.ToLab()
+	var populations map[color.ARGB]int
+	switch opts.Method {
+	case enums.QuantizerWu:
+		populations, err = kmeans(ctx, pixels, wu, 0)
+	case enums.QuantizerKmeans:
+		populations, err = kmeans(ctx, pixels, wu, opts.Iterations)
+	default:
+		colors := make([]color.Lab, len(wu))
+		for i, c := range wu {
+			colors[i] = c.ToLab()
+		}
+		populations, err = quantizer.QuantizeWsMeansContext(ctx, pixels, colors, opts.Iterations)
 	}
-
-	celebi, err := quantizer.QuantizeWsMeansContext(ctx, pixels, colors, 10)
 	if err != nil {
 		return Quantized{}, err
 	}
 
-	return Quantized{celebi, wu}, nil
+	return Quantized{populations, wu}, nil
+}
+
+// Merge combines quantized colors of several sources with the weight of the
+// same index. Every source is scaled to the same total population first, so
+// the weights decide how much each source counts and not its size. Celebi
+// populations are added and Wu palettes are unioned.
+func Merge(quantized []Quantized, weights []float64) Quantized {
+	if len(quantized) == 1 {
+		return quantized[0]
+	}
+
+	totals := make([]int, len(quantized))
+	largest := 0
+	for i, q := range quantized {
+		for _, population := range q.Celebi {
+			totals[i] += population
+		}
+		largest = max(largest, totals[i])
+	}
+
+	merged := Quantized{Celebi: map[color.ARGB]int{}}
+	seen := map[color.ARGB]bool{}
+	for i, q := range quantized {
+		weight := 1.0
+		if i < len(weights) {
+			weight = weights[i]
+		}
+		if totals[i] > 0 {
+			scale := weight * float64(largest) / float64(totals[i])
+			for c, population := range q.Celebi {
+				merged.Celebi[c] += int(math.Round(float64(population) * scale))
+			}
+		}
+		for _, c := range q.Wu {
+			if !seen[c] {
+				seen[c] = true
+				merged.Wu = append(merged.Wu, c)
+			}
+		}
+	}
+	return merged
 }
 
 // ErrNoColorFound means no color found from imput image.
 var ErrNoColorFound = errors.New("no color found")
 
+// ErrInvalidRank means the source rank is not one of the scored colors.
+var ErrInvalidRank = errors.New("invalid source rank")
+
+// Candidates returns up to n scored candidates for the source color of
+// quantized colors, best first.
+func Candidates(quantized Quantized, n int) []color.ARGB {
+	return score.Score(quantized.Celebi, score.WithFilter(), score.WithLimit(n))
+}
+
 // Colors is key and color.
 type Colors = map[string]color.ARGB
 
@@ -49,7 +112,7 @@ func GenerateFromPixels(
 	cfg Config,
 	sourceColor color.ARGB,
 ) (Colors, error) {
-	q, err := Quantize(ctx, pixels)
+	q, err := Quantize(ctx, pixels, GetQuantizeOptions(ctx))
 	if err != nil {
 		return nil, err
 	}
@@ -57,19 +120,22 @@ func GenerateFromPixels(
 	return GenerateFromQuantized(q, cfg, sourceColor)
 }
 
-// GenerateFromQuantized generates color from a cached quantized.
+// GenerateFromQuantized generates color from a cached quantized. The source
+// color is the scored color of cfg.SourceRank unless sourceColor is set.
 func GenerateFromQuantized(quantized Quantized, cfg Config, sourceColor color.ARGB) (Colors, error) {
-	celebi := quantized.Celebi
-
 	var sourceHct color.Hct
 	if sourceColor.Alpha() != 0 {
 		sourceHct = sourceColor.ToHct()
 	} else {
-		scored := score.Score(celebi, score.WithFilter())
+		rank := max(cfg.SourceRank, 1)
+		scored := Candidates(quantized, max(rank, 4))
 		if len(scored) == 0 {
 			return nil, ErrNoColorFound
 		}
-		sourceHct = scored[0].ToHct()
+		if rank > len(scored) {
+			return nil, fmt.Errorf("%w: %d, only %d colors were scored", ErrInvalidRank, rank, len(scored))
+		}
+		sourceHct = scored[rank-1].ToHct()
 	}
 
 	scheme := dynamic.NewDynamicScheme(
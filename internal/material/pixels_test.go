@@ -0,0 +1,105 @@
+package material
+
+import (
+	"maps"
+	"slices"
+	"testing"
+
+	"github.com/Nadim147c/material/v3/color"
+)
+
+func TestMerge(t *testing.T) {
+	const (
+		red   color.ARGB = 0xffff0000
+		green color.ARGB = 0xff00ff00
+		blue  color.ARGB = 0xff0000ff
+	)
+
+	testdata := []struct {
+		name      string
+		quantized []Quantized
+		weights   []float64
+		want      map[color.ARGB]int
+		wu        []color.ARGB
+	}{
+		{
+			"single source is unchanged",
+			[]Quantized{{Celebi: map[color.ARGB]int{red: 3}, Wu: []color.ARGB{red}}},
+			[]float64{5},
+			map[color.ARGB]int{red: 3},
+			[]color.ARGB{red},
+		},
+		{
+			"same size",
+			[]Quantized{
+				{Celebi: map[color.ARGB]int{red: 10}},
+				{Celebi: map[color.ARGB]int{green: 10}},
+			},
+			[]float64{1, 1},
+			map[color.ARGB]int{red: 10, green: 10},
+			nil,
+		},
+		{
+			"smaller source is scaled up",
+			[]Quantized{
+				{Celebi: map[color.ARGB]int{red: 100}},
+				{Celebi: map[color.ARGB]int{green: 5, blue: 5}},
+			},
+			[]float64{1, 1},
+			map[color.ARGB]int{red: 100, green: 50, blue: 50},
+			nil,
+		},
+		{
+			"weights",
+			[]Quantized{
+				{Celebi: map[color.ARGB]int{red: 10}},
+				{Celebi: map[color.ARGB]int{green: 40}},
+			},
+			[]float64{2, 0.5},
+			map[color.ARGB]int{red: 80, green: 20},
+			nil,
+		},
+		{
+			"shared colors are added",
+			[]Quantized{
+				{Celebi: map[color.ARGB]int{red: 4, green: 4}, Wu: []color.ARGB{red, green}},
+				{Celebi: map[color.ARGB]int{red: 8}, Wu: []color.ARGB{red, blue}},
+			},
+			[]float64{1, 1},
+			map[color.ARGB]int{red: 12, green: 4},
+			[]color.ARGB{red, green, blue},
+		},
+		{
+			"missing weights count once",
+			[]Quantized{
+				{Celebi: map[color.ARGB]int{red: 10}},
+				{Celebi: map[color.ARGB]int{green: 10}},
+			},
+			[]float64{3},
+			map[color.ARGB]int{red: 30, green: 10},
+			nil,
+		},
+		{
+			"empty source",
+			[]Quantized{
+				{Celebi: map[color.ARGB]int{red: 10}},
+				{},
+			},
+			[]float64{1, 1},
+			map[color.ARGB]int{red: 10},
+			nil,
+		},
+	}
+
+	for _, test := range testdata {
+		t.Run(test.name, func(t *testing.T) {
+			got := Merge(test.quantized, test.weights)
+			if !maps.Equal(got.Celebi, test.want) {
+				t.Errorf("Celebi = %v, want %v", got.Celebi, test.want)
+			}
+			if !slices.Equal(got.Wu, test.wu) {
+				t.Errorf("Wu = %v, want %v", got.Wu, test.wu)
+			}
+		})
+	}
+}
@@ -0,0 +1,189 @@
+package material
+
+import (
+	"cmp"
+	"context"
+	"log/slog"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/Nadim147c/material/v3/color"
+	"github.com/Nadim147c/rong/v5/internal/config"
+	"github.com/Nadim147c/rong/v5/internal/config/enums"
+)
+
+// QuantizeOptions configures quantization of pixels.
+type QuantizeOptions struct {
+	// Method is the quantization algorithm. Wu colors seed the refinement of
+	// celebi (weighted k-means in Lab) and kmeans (k-means in OkLab).
+	Method enums.Quantizer
+	// MaxColors is the maximum number of quantized colors.
+	MaxColors int
+	// Iterations is the number of refinement iterations after Wu.
+	Iterations int
+	// Pixels outside of MinTone to MaxTone or below MinChroma are ignored,
+	// unless no pixel is left.
+	MinTone, MaxTone, MinChroma float64
+}
+
+// GetQuantizeOptions returns the configured quantize options of ctx.
+func GetQuantizeOptions(ctx context.Context) QuantizeOptions {
+	return QuantizeOptions{
+		Method:     config.QuantizeMethod.Value(ctx),
+		MaxColors:  config.QuantizeMaxColors.Value(ctx),
+		Iterations: config.QuantizeIterations.Value(ctx),
+		MinTone:    config.QuantizeMinTone.Value(ctx),
+		MaxTone:    config.QuantizeMaxTone.Value(ctx),
+		MinChroma:  config.QuantizeMinChroma.Value(ctx),
+	}
+}
+
+// Key returns a string identifying options that differ from the defaults, or
+// an empty string for the defaults. It is used as a variant of cache keys.
+func (o QuantizeOptions) Key() string {
+	var parts []string
+	if o.Method != config.QuantizeMethod.Default() {
+		parts = append(parts, "quantizer="+o.Method.String())
+	}
+	if o.MaxColors != config.QuantizeMaxColors.Default() {
+		parts = append(parts, "max-colors="+strconv.Itoa(o.MaxColors))
+	}
+	if o.Method != enums.QuantizerWu && o.Iterations != config.QuantizeIterations.Default() {
+		parts = append(parts, "iterations="+strconv.Itoa(o.Iterations))
+	}
+	if o.MinTone != config.QuantizeMinTone.Default() {
+		parts = append(parts, "min-tone="+formatFloat(o.MinTone))
+	}
+	if o.MaxTone != config.QuantizeMaxTone.Default() {
+		parts = append(parts, "max-tone="+formatFloat(o.MaxTone))
+	}
+	if o.MinChroma != config.QuantizeMinChroma.Default() {
+		parts = append(parts, "min-chroma="+formatFloat(o.MinChroma))
+	}
+	return strings.Join(parts, ";")
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+// filter returns the pixels within the tone and chroma limits of o. Every
+// pixel is returned when none is within them, so flat images still get colors.
+func (o QuantizeOptions) filter(pixels []color.ARGB) []color.ARGB {
+	if o.MinTone <= 0 && o.MaxTone >= 100 && o.MinChroma <= 0 {
+		return pixels
+	}
+
+	keep := map[color.ARGB]bool{}
+	filtered := make([]color.ARGB, 0, len(pixels))
+	for _, p := range pixels {
+		ok, seen := keep[p]
+		if !seen {
+			hct := p.ToHct()
+			ok = hct.Tone >= o.MinTone && hct.Tone <= o.MaxTone && hct.Chroma >= o.MinChroma
+			keep[p] = ok
+		}
+		if ok {
+			filtered = append(filtered, p)
+		}
+	}
+
+	if len(filtered) == 0 {
+		slog.Warn("No pixel is within the quantize filters, using every pixel",
+			"min-tone", o.MinTone, "max-tone", o.MaxTone, "min-chroma", o.MinChroma)
+		return pixels
+	}
+	return filtered
+}
+
+// kmeans clusters pixels in OkLab starting from the seeds and returns the
+// population of every cluster. Zero iterations only counts the pixels nearest
+// to every seed.
+func kmeans(
+	ctx context.Context,
+	pixels []color.ARGB,
+	seeds []color.ARGB,
+	iterations int,
+) (map[color.ARGB]int, error) {
+	if len(seeds) == 0 {
+		return map[color.ARGB]int{}, nil
+	}
+
+	// Unique colors are sorted, so sums and the result don't depend on the
+	// order of map iteration.
+	counts := map[color.ARGB]int{}
+	for _, p := range pixels {
+		counts[p]++
+	}
+	unique := make([]color.ARGB, 0, len(counts))
+	for c := range counts {
+		unique = append(unique, c)
+	}
+	slices.SortFunc(unique, func(a, b color.ARGB) int { return cmp.Compare(a, b) })
+	points := make([]color.OkLab, len(unique))
+	for i, c := range unique {
+		points[i] = c.ToOkLab()
+	}
+
+	colors := slices.Clone(seeds)
+	centers := make([]color.OkLab, len(colors))
+	for i, c := range colors {
+		centers[i] = c.ToOkLab()
+	}
+
+	assign := make([]int, len(points))
+	nearest := func() bool {
+		changed := false
+		for i, p := range points {
+			best, bestDist := 0, math.Inf(1)
+			for j, c := range centers {
+				dL, dA, dB := p.L-c.L, p.A-c.A, p.B-c.B
+				if dist := dL*dL + dA*dA + dB*dB; dist < bestDist {
+					best, bestDist = j, dist
+				}
+			}
+			if assign[i] != best {
+				assign[i], changed = best, true
+			}
+		}
+		return changed
+	}
+
+	nearest()
+	for range iterations {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		sums := make([]color.OkLab, len(centers))
+		weights := make([]float64, len(centers))
+		for i, p := range points {
+			w := float64(counts[unique[i]])
+			j := assign[i]
+			sums[j].L += p.L * w
+			sums[j].A += p.A * w
+			sums[j].B += p.B * w
+			weights[j] += w
+		}
+		for j := range centers {
+			// Empty clusters keep their center.
+			if weights[j] == 0 {
+				continue
+			}
+			centers[j] = color.OkLab{L: sums[j].L / weights[j], A: sums[j].A / weights[j], B: sums[j].B / weights[j]}
+			colors[j] = centers[j].ToARGB()
+		}
+
+		if !nearest() {
+			break
+		}
+	}
+
+	populations := map[color.ARGB]int{}
+	for i, j := range assign {
+		populations[colors[j]] += counts[unique[i]]
+	}
+	return populations, nil
+}
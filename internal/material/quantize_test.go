@@ -0,0 +1,168 @@
+package material
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"slices"
+	"testing"
+
+	"github.com/Nadim147c/material/v3/color"
+	"github.com/Nadim147c/rong/v5/internal/config"
+)
+
+func TestQuantizeOptionsKey(t *testing.T) {
+	testdata := []struct {
+		name   string
+		config map[string]any
+		want   string
+	}{
+		{"defaults", nil, ""},
+		{"method", map[string]any{"quantize.method": "kmeans"}, "quantizer=kmeans"},
+		{"max colors", map[string]any{"quantize.max-colors": 16}, "max-colors=16"},
+		{"iterations", map[string]any{"quantize.iterations": 5}, "iterations=5"},
+		{"iterations of wu", map[string]any{"quantize.method": "wu", "quantize.iterations": 5}, "quantizer=wu"},
+		{"tones", map[string]any{"quantize.min-tone": 10, "quantize.max-tone": 90.5}, "min-tone=10;max-tone=90.5"},
+		{"min chroma", map[string]any{"quantize.min-chroma": 0.25}, "min-chroma=0.25"},
+		{
+			"every option",
+			map[string]any{
+				"quantize.method":     "kmeans",
+				"quantize.max-colors": 8,
+				"quantize.iterations": 3,
+				"quantize.min-tone":   5,
+				"quantize.max-tone":   95,
+				"quantize.min-chroma": 2,
+			},
+			"quantizer=kmeans;max-colors=8;iterations=3;min-tone=5;max-tone=95;min-chroma=2",
+		},
+		{"values equal to defaults", map[string]any{"quantize.max-colors": 100, "quantize.method": "celebi"}, ""},
+	}
+
+	for _, test := range testdata {
+		t.Run(test.name, func(t *testing.T) {
+			store := config.New()
+			for key, value := range test.config {
+				store.Set(key, value)
+			}
+			ctx := config.WithStore(t.Context(), store)
+
+			if got := GetQuantizeOptions(ctx).Key(); got != test.want {
+				t.Errorf("Key = %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestKmeans(t *testing.T) {
+	const (
+		black color.ARGB = 0xff000000
+		gray  color.ARGB = 0xff202020
+		white color.ARGB = 0xffffffff
+		red   color.ARGB = 0xffff0000
+	)
+
+	// repeat returns n copies of c.
+	repeat := func(c color.ARGB, n int) []color.ARGB {
+		return slices.Repeat([]color.ARGB{c}, n)
+	}
+
+	testdata := []struct {
+		name       string
+		pixels     []color.ARGB
+		seeds      []color.ARGB
+		iterations int
+		// populations are the sorted populations of the clusters.
+		populations []int
+		// kept are seeds whose cluster keeps their color and moved are seeds
+		// whose cluster moves away from it.
+		kept, moved []color.ARGB
+	}{
+		{"no seeds", repeat(red, 3), nil, 10, nil, nil, nil},
+		{"no pixels", nil, []color.ARGB{red}, 10, nil, nil, nil},
+		{
+			"zero iterations count nearest seeds",
+			slices.Concat(repeat(black, 3), repeat(gray, 1), repeat(white, 2)),
+			[]color.ARGB{black, white},
+			0,
+			[]int{2, 4},
+			[]color.ARGB{black, white},
+			nil,
+		},
+		{
+			"iterations move centers",
+			slices.Concat(repeat(black, 3), repeat(gray, 1), repeat(white, 2)),
+			[]color.ARGB{black, white},
+			10,
+			[]int{2, 4},
+			[]color.ARGB{white},
+			[]color.ARGB{black},
+		},
+		{
+			"clusters of a single color keep it",
+			slices.Concat(repeat(black, 2), repeat(white, 5)),
+			[]color.ARGB{black, white},
+			10,
+			[]int{2, 5},
+			[]color.ARGB{black, white},
+			nil,
+		},
+		{
+			"empty clusters are dropped",
+			repeat(white, 4),
+			[]color.ARGB{white, black},
+			10,
+			[]int{4},
+			[]color.ARGB{white},
+			[]color.ARGB{black},
+		},
+	}
+
+	for _, test := range testdata {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := kmeans(t.Context(), test.pixels, test.seeds, test.iterations)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			populations := slices.Sorted(maps.Values(got))
+			if !slices.Equal(populations, test.populations) {
+				t.Errorf("populations = %v, want %v", populations, test.populations)
+			}
+			for _, c := range test.kept {
+				if _, ok := got[c]; !ok {
+					t.Errorf("kmeans = %v, want a cluster of %v", got, c)
+				}
+			}
+			for _, c := range test.moved {
+				if _, ok := got[c]; ok {
+					t.Errorf("kmeans = %v, want no cluster of %v", got, c)
+				}
+			}
+
+			// The result doesn't depend on the order of pixels.
+			reversed := slices.Clone(test.pixels)
+			slices.Reverse(reversed)
+			again, err := kmeans(t.Context(), reversed, test.seeds, test.iterations)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !maps.Equal(got, again) {
+				t.Errorf("kmeans of reversed pixels = %v, want %v", again, got)
+			}
+		})
+	}
+}
+
+func TestKmeansCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+
+	pixels := []color.ARGB{0xff000000, 0xffffffff}
+	if _, err := kmeans(ctx, pixels, pixels, 1); !errors.Is(err, context.Canceled) {
+		t.Errorf("kmeans error = %v, want %v", err, context.Canceled)
+	}
+	if _, err := kmeans(ctx, pixels, pixels, 0); err != nil {
+		t.Errorf("kmeans without iterations error = %v, want nil", err)
+	}
+}
@@ -0,0 +1,129 @@
+package media
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/gif"
+	"os"
+
+	"github.com/Nadim147c/rong/v5/internal/ffmpeg"
+
+	_ "image/jpeg" // for jpeg decoding
+	_ "image/png"  // for png decoding
+
+	_ "golang.org/x/image/bmp"  // for bmp decoding
+	_ "golang.org/x/image/tiff" // for tiff decoding
+	_ "golang.org/x/image/webp" // for webp decoding
+)
+
+// Built-in decoders.
+var (
+	Native = Decoder{Name: "go", Decode: decodeImage}
+	GIF    = Decoder{Name: "go", Decode: decodeGIF}
+	FFmpeg = Decoder{Name: "ffmpeg", Decode: decodeFFmpeg, Crops: true, Scales: true}
+)
+
+func init() {
+	for _, mime := range []string{
+		"image/jpeg", "image/png", "image/vnd.mozilla.apng",
+		"image/webp", "image/bmp", "image/tiff",
+	} {
+		Register(mime, Image, Native)
+	}
+	Register("image/gif", Image, GIF)
+
+	for _, mime := range []string{
+		"image/avif", "image/heic", "image/heif", "image/jxl", "image/jp2",
+		"image/svg+xml", "image/x-icon", "image/vnd.radiance",
+		"image/x-portable-bitmap", "image/x-portable-graymap", "image/x-portable-pixmap",
+	} {
+		Register(mime, Image, FFmpeg)
+	}
+	Register("image/heic-sequence", Video, FFmpeg)
+	Register("image/heif-sequence", Video, FFmpeg)
+	RegisterVideo(FFmpeg)
+}
+
+// decodeFFmpeg decodes videos and images with ffmpeg.
+func decodeFFmpeg(ctx context.Context, path string, opts Options, frame FrameFunc) error {
+	return ffmpeg.Frames(ctx, path, opts.ffmpeg(), frame)
+}
+
+// decodeImage decodes a still image with the image package.
+func decodeImage(_ context.Context, path string, _ Options, frame FrameFunc) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open image file: %w", err)
+	}
+	defer file.Close()
+
+	img, _, err := image.Decode(file)
+	if err != nil {
+		return fmt.Errorf("failed to decode image: %w", err)
+	}
+
+	return frame(img, 1)
+}
+
+// decodeGIF decodes up to frames number of frames evenly spread over a gif.
+// Frames only store the area that changed, so every frame is drawn onto a
+// canvas that is disposed of as the gif says.
+func decodeGIF(ctx context.Context, path string, opts Options, frame FrameFunc) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open image file: %w", err)
+	}
+	defer file.Close()
+
+	g, err := gif.DecodeAll(file)
+	if err != nil {
+		return fmt.Errorf("failed to decode gif: %w", err)
+	}
+
+	n := min(max(opts.Frames, 1), len(g.Image))
+	sampled := make(map[int]bool, n)
+	for i := range n {
+		sampled[i*len(g.Image)/n] = true
+	}
+
+	bounds := image.Rect(0, 0, g.Config.Width, g.Config.Height)
+	for _, img := range g.Image {
+		bounds = bounds.Union(img.Bounds())
+	}
+	canvas := image.NewRGBA(bounds)
+	var previous *image.RGBA
+
+	for i, img := range g.Image {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		disposal := byte(gif.DisposalNone)
+		if i < len(g.Disposal) {
+			disposal = g.Disposal[i]
+		}
+		if disposal == gif.DisposalPrevious {
+			if previous == nil {
+				previous = image.NewRGBA(bounds)
+			}
+			copy(previous.Pix, canvas.Pix)
+		}
+
+		draw.Draw(canvas, img.Bounds(), img, img.Bounds().Min, draw.Over)
+		if sampled[i] {
+			if err := frame(canvas, 1/float64(n)); err != nil {
+				return err
+			}
+		}
+
+		switch disposal {
+		case gif.DisposalBackground:
+			draw.Draw(canvas, img.Bounds(), image.Transparent, image.Point{}, draw.Src)
+		case gif.DisposalPrevious:
+			copy(canvas.Pix, previous.Pix)
+		}
+	}
+	return nil
+}
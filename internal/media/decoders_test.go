@@ -0,0 +1,88 @@
+package media
+
+import (
+	"image"
+	"image/color"
+	"image/gif"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var (
+	red         = color.RGBA{0xff, 0, 0, 0xff}
+	green       = color.RGBA{0, 0xff, 0, 0xff}
+	blue        = color.RGBA{0, 0, 0xff, 0xff}
+	transparent = color.RGBA{}
+)
+
+// paletted returns a frame of rect filled with c.
+func paletted(rect image.Rectangle, c color.Color) *image.Paletted {
+	img := image.NewPaletted(rect, color.Palette{transparent, c})
+	for i := range img.Pix {
+		img.Pix[i] = 1
+	}
+	return img
+}
+
+func TestDecodeGIF(t *testing.T) {
+	full := image.Rect(0, 0, 4, 4)
+	corner := image.Rect(0, 0, 2, 2)
+	dot := image.Rect(3, 3, 4, 4)
+
+	testdata := []struct {
+		name     string
+		disposal byte
+		// want are the colors at the corner and the dot of every frame.
+		want [][2]color.RGBA
+	}{
+		{"none", gif.DisposalNone, [][2]color.RGBA{{red, red}, {blue, red}, {blue, green}}},
+		{"background", gif.DisposalBackground, [][2]color.RGBA{{red, red}, {blue, red}, {transparent, green}}},
+		{"previous", gif.DisposalPrevious, [][2]color.RGBA{{red, red}, {blue, red}, {red, green}}},
+	}
+
+	for _, test := range testdata {
+		t.Run(test.name, func(t *testing.T) {
+			g := &gif.GIF{
+				Image:    []*image.Paletted{paletted(full, red), paletted(corner, blue), paletted(dot, green)},
+				Delay:    []int{0, 0, 0},
+				Disposal: []byte{gif.DisposalNone, test.disposal, gif.DisposalNone},
+				Config:   image.Config{Width: 4, Height: 4},
+			}
+
+			path := filepath.Join(t.TempDir(), "a.gif")
+			file, err := os.Create(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := gif.EncodeAll(file, g); err != nil {
+				t.Fatal(err)
+			}
+			file.Close()
+
+			var got [][2]color.RGBA
+			err = decodeGIF(t.Context(), path, Options{Frames: 3}, func(img image.Image, _ float64) error {
+				if img.Bounds() != full {
+					t.Errorf("frame bounds = %v, want %v", img.Bounds(), full)
+				}
+				at := func(x, y int) color.RGBA {
+					return color.RGBAModel.Convert(img.At(x, y)).(color.RGBA)
+				}
+				got = append(got, [2]color.RGBA{at(0, 0), at(3, 3)})
+				return nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(got) != len(test.want) {
+				t.Fatalf("decoded %d frames, want %d", len(got), len(test.want))
+			}
+			for i := range got {
+				if got[i] != test.want[i] {
+					t.Errorf("frame %d = %v, want %v", i, got[i], test.want[i])
+				}
+			}
+		})
+	}
+}
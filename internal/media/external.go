@@ -0,0 +1,187 @@
+package media
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"io"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Nadim147c/rong/v5/internal/config"
+	"github.com/Nadim147c/rong/v5/internal/config/enums"
+	"github.com/Nadim147c/rong/v5/internal/ffmpeg"
+	"github.com/Nadim147c/rong/v5/internal/ppm"
+	shlex "github.com/carapace-sh/carapace-shlex"
+)
+
+// external returns the configured external decoder of a file. Patterns with a
+// slash match the mimetype and other patterns match the file extension. Longer
+// patterns are tried first.
+func external(ctx context.Context, file, mime string) (Decoder, bool) {
+	decoders := config.Decoders.Value(ctx)
+	if len(decoders) == 0 {
+		return Decoder{}, false
+	}
+
+	patterns := make([]string, 0, len(decoders))
+	for pattern := range decoders {
+		patterns = append(patterns, pattern)
+	}
+	slices.SortFunc(patterns, func(a, b string) int {
+		return cmp.Or(cmp.Compare(len(b), len(a)), strings.Compare(a, b))
+	})
+
+	ext := strings.ToLower(strings.TrimPrefix(filepath.Ext(file), "."))
+	for _, pattern := range patterns {
+		name := ext
+		if strings.Contains(pattern, "/") {
+			name = mime
+		}
+		if ok, _ := path.Match(strings.ToLower(pattern), name); ok && name != "" {
+			return newExternal(decoders[pattern]), true
+		}
+	}
+	return Decoder{}, false
+}
+
+// newExternal returns a decoder running the command of d.
+func newExternal(d config.ExternalDecoder) Decoder {
+	name := d.Command
+	if fields := strings.Fields(d.Command); len(fields) > 0 {
+		name = filepath.Base(strings.Trim(fields[0], `'"`))
+	}
+	return Decoder{
+		Name:    name,
+		Command: d.Command,
+		Scales:  strings.Contains(d.Command, "{size}"),
+		Decode: func(ctx context.Context, path string, opts Options, frame FrameFunc) error {
+			return decodeExternal(ctx, path, d, opts, frame)
+		},
+	}
+}
+
+// decodeExternal runs the command of d and reads the images it writes to
+// stdout. The file is passed as the {input} argument, or on stdin when the
+// command has none. Every image of the output is a frame.
+func decodeExternal(
+	ctx context.Context,
+	path string,
+	d config.ExternalDecoder,
+	opts Options,
+	frame FrameFunc,
+) error {
+	size := cmp.Or(opts.FrameSize, config.FrameSize.Default())
+
+	tokens, err := shlex.Split(d.Command)
+	if err != nil {
+		return fmt.Errorf("invalid command %q: %w", d.Command, err)
+	}
+	args := tokens.Words().Strings()
+	if len(args) == 0 {
+		return fmt.Errorf("invalid command %q: it is empty", d.Command)
+	}
+
+	stdin := true
+	replacer := strings.NewReplacer("{input}", path, "{size}", strconv.Itoa(size))
+	for i, arg := range args {
+		if strings.Contains(arg, "{input}") {
+			stdin = false
+		}
+		args[i] = replacer.Replace(arg)
+	}
+
+	if _, err := exec.LookPath(args[0]); err != nil {
+		return fmt.Errorf("%s is %w", args[0], ffmpeg.ErrMissing)
+	}
+
+	timeout := cmp.Or(d.Timeout, config.DecodeTimeout.Value(ctx))
+	deadline := ctx
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		deadline, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	ctx, cancel := context.WithCancel(deadline)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	// Children of the command may hold its output open after it is killed.
+	cmd.WaitDelay = time.Second
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if stdin {
+		file, err := os.Open(path)
+		if err != nil {
+			return fmt.Errorf("failed to open file: %w", err)
+		}
+		defer file.Close()
+		cmd.Stdin = file
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to start %s: %w", args[0], err)
+	}
+
+	// stop kills the command after an error.
+	stop := func(err error) error {
+		cancel()
+		_ = cmd.Wait()
+		if errors.Is(deadline.Err(), context.DeadlineExceeded) {
+			return fmt.Errorf("timed out after %s", timeout)
+		}
+		return err
+	}
+
+	r := ppm.NewReader(stdout)
+	frames := 0
+	for {
+		var img image.Image
+		var err error
+		if d.Format == enums.DecoderFormatRgb24 {
+			img, err = r.NextRaw(size, size)
+		} else {
+			img, err = r.Next()
+		}
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return stop(fmt.Errorf("%s output: %w", args[0], err))
+		}
+		frames++
+		if err := frame(img, 1); err != nil {
+			return stop(err)
+		}
+	}
+
+	if err := cmd.Wait(); err != nil {
+		if errors.Is(deadline.Err(), context.DeadlineExceeded) {
+			return fmt.Errorf("timed out after %s", timeout)
+		}
+		msg := strings.TrimSpace(stderr.String())
+		if i := strings.LastIndexByte(msg, '\n'); i >= 0 {
+			msg = msg[i+1:]
+		}
+		if msg != "" {
+			return fmt.Errorf("%w: %s", err, msg)
+		}
+		return err
+	}
+	if frames == 0 {
+		return fmt.Errorf("%s wrote no %s image to stdout", args[0], d.Format)
+	}
+	return nil
+}
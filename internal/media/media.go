@@ -0,0 +1,322 @@
+// Package media detects the type of image and video files and decodes their
+// pixels with a registered decoder.
+package media
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/Nadim147c/material/v3/color"
+	"github.com/Nadim147c/rong/v5/internal/config"
+	"github.com/Nadim147c/rong/v5/internal/config/enums"
+	"github.com/Nadim147c/rong/v5/internal/ffmpeg"
+	"github.com/Nadim147c/rong/v5/internal/material"
+	"github.com/gabriel-vasile/mimetype"
+)
+
+// ErrUnsupported means the file is not a supported image or video.
+var ErrUnsupported = errors.New("unsupported media type")
+
+// Kind is the kind of a media file.
+type Kind int
+
+// Kinds of media.
+const (
+	Image Kind = iota + 1
+	Video
+)
+
+// Options configures decoding of pixels.
+type Options struct {
+	// Frames is the number of frames sampled from videos and animations.
+	Frames int
+	// Duration is the number of seconds of videos sampled by the window
+	// strategy.
+	Duration float64
+	// Sampling is the strategy to pick frames of videos.
+	Sampling enums.Sampling
+	// Offset is the time in seconds of videos to start sampling from.
+	Offset float64
+	// SceneThreshold is the minimum change between frames to start a scene.
+	SceneThreshold float64
+	// MaxPixels is the maximum number of decoded pixels. Zero is unlimited.
+	MaxPixels int
+	// Region is the part of every frame used for colors.
+	Region Region
+	// FrameSize is the maximum width and height of frames decoded by ffmpeg.
+	FrameSize int
+	// Quantize configures quantization of the decoded pixels.
+	Quantize material.QuantizeOptions
+}
+
+// GetOptions returns the configured decoding options of ctx.
+func GetOptions(ctx context.Context) (Options, error) {
+	crop, err := ParseCrop(config.Crop.Value(ctx))
+	if err != nil {
+		return Options{}, err
+	}
+	return Options{
+		Frames:         config.FFmpegFrames.Value(ctx),
+		Duration:       config.FFmpegDuration.Value(ctx).Seconds(),
+		Sampling:       config.Sampling.Value(ctx),
+		Offset:         config.FFmpegOffset.Value(ctx).Seconds(),
+		SceneThreshold: config.SceneThreshold.Value(ctx),
+		MaxPixels:      config.QuantizeMaxPixels.Value(ctx),
+		FrameSize:      config.FrameSize.Value(ctx),
+		Quantize:       material.GetQuantizeOptions(ctx),
+		Region: Region{
+			Crop:         crop,
+			TrimBorders:  config.TrimBorders.Value(ctx),
+			CenterWeight: config.CenterWeight.Value(ctx),
+		},
+	}, nil
+}
+
+// Key returns a string identifying options that change the decoded pixels of
+// m or their quantized colors, or an empty string for the defaults. It is used
+// as a variant of cache keys.
+func (o Options) Key(m Media) string {
+	parts := []string{o.Region.Key()}
+	if m.IsVideo() {
+		parts = append(parts, o.samplingKey())
+	}
+	if m.Decoder.Command != "" {
+		parts = append(parts, "decoder="+m.Decoder.Command)
+	}
+	if m.Decoder.Scales && o.FrameSize != config.FrameSize.Default() {
+		parts = append(parts, "frame-size="+strconv.Itoa(o.FrameSize))
+	}
+	if o.MaxPixels != config.QuantizeMaxPixels.Default() {
+		parts = append(parts, "max-pixels="+strconv.Itoa(o.MaxPixels))
+	}
+	parts = append(parts, o.Quantize.Key())
+	return strings.Trim(strings.Join(parts, ";"), ";")
+}
+
+// samplingKey returns a string identifying the sampling of videos. Values
+// matching the configuration defaults are left out, so caches of the default
+// sampling keep the plain content key.
+func (o Options) samplingKey() string {
+	var parts []string
+	if o.Sampling != config.Sampling.Default() {
+		parts = append(parts, "sample="+o.Sampling.String())
+	}
+	if o.Frames != config.FFmpegFrames.Default() {
+		parts = append(parts, "frames="+strconv.Itoa(o.Frames))
+	}
+	if o.Offset != 0 {
+		parts = append(parts, "offset="+formatFloat(o.Offset))
+	}
+	if o.Sampling == enums.SamplingWindow && o.Duration != config.FFmpegDuration.Default().Seconds() {
+		parts = append(parts, "duration="+formatFloat(o.Duration))
+	}
+	if o.Sampling == enums.SamplingScene && o.SceneThreshold != config.SceneThreshold.Default() {
+		parts = append(parts, "scene-threshold="+formatFloat(o.SceneThreshold))
+	}
+	return strings.Join(parts, ";")
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+// ffmpeg returns the options of decoding with ffmpeg.
+func (o Options) ffmpeg() ffmpeg.Options {
+	return ffmpeg.Options{
+		Sampling: ffmpeg.Sampling{
+			Strategy:  o.Sampling,
+			Frames:    o.Frames,
+			Offset:    o.Offset,
+			Duration:  o.Duration,
+			Threshold: o.SceneThreshold,
+		},
+		Crop:    o.Region.Crop.Filter(),
+		MaxSize: o.FrameSize,
+	}
+}
+
+// DecodeFunc decodes frames of the media file at path and calls frame with
+// each of them and the share of the media it stands for. Decoders may reuse
+// the image after frame returns.
+type DecodeFunc func(ctx context.Context, path string, opts Options, frame FrameFunc) error
+
+// FrameFunc receives decoded frames.
+type FrameFunc func(img image.Image, weight float64) error
+
+// Decoder decodes media types.
+type Decoder struct {
+	Name   string
+	Decode DecodeFunc
+	// Crops reports whether Decode applies the crop of Options.Region to
+	// frames itself.
+	Crops bool
+	// Scales reports whether Decode scales frames to Options.FrameSize.
+	Scales bool
+	// Command is the command template of external decoders.
+	Command string
+}
+
+// Type is a supported media type.
+type Type struct {
+	Mime    string
+	Kind    Kind
+	Decoder Decoder
+}
+
+var registry struct {
+	sync.RWMutex
+	types []Type
+	video Decoder
+}
+
+// Register registers the decoder of a media type. A later registration of the
+// same type replaces the decoder.
+func Register(mime string, kind Kind, decoder Decoder) {
+	registry.Lock()
+	defer registry.Unlock()
+
+	for i, t := range registry.types {
+		if t.Mime == mime {
+			registry.types[i] = Type{mime, kind, decoder}
+			return
+		}
+	}
+	registry.types = append(registry.types, Type{mime, kind, decoder})
+}
+
+// RegisterVideo registers the decoder of video types without a decoder of their
+// own.
+func RegisterVideo(decoder Decoder) {
+	registry.Lock()
+	defer registry.Unlock()
+	registry.video = decoder
+}
+
+// Media is a detected media file.
+type Media struct {
+	Path string
+	Type
+}
+
+// IsVideo reports whether the media is a video.
+func (m Media) IsVideo() bool {
+	return m.Kind == Video
+}
+
+// Detect detects the media type of the file at path from its content. External
+// decoders of the configuration of ctx are used ahead of registered decoders.
+func Detect(ctx context.Context, path string) (Media, error) {
+	mtype, err := mimetype.DetectFile(path)
+	if err != nil {
+		return Media{}, fmt.Errorf("failed to get media type: %w", err)
+	}
+
+	registry.RLock()
+	defer registry.RUnlock()
+
+	mime, _, _ := strings.Cut(mtype.String(), ";")
+	if decoder, ok := external(ctx, path, mime); ok {
+		kind := Image
+		for _, t := range registry.types {
+			if mtype.Is(t.Mime) {
+				kind = t.Kind
+			}
+		}
+		if strings.HasPrefix(mime, "video/") {
+			kind = Video
+		}
+		return Media{path, Type{mime, kind, decoder}}, nil
+	}
+
+	for _, t := range registry.types {
+		if mtype.Is(t.Mime) {
+			return Media{path, t}, nil
+		}
+	}
+
+	if strings.HasPrefix(mtype.String(), "video/") && registry.video.Decode != nil {
+		return Media{path, Type{mtype.String(), Video, registry.video}}, nil
+	}
+
+	return Media{}, fmt.Errorf("%w: %s", ErrUnsupported, mtype.String())
+}
+
+// IsMedia reports whether the file at path is a supported image or video.
+func IsMedia(ctx context.Context, path string) bool {
+	_, err := Detect(ctx, path)
+	return err == nil
+}
+
+// Pixels decodes pixels of the region of every frame of the media. Frames
+// count by their weight, whatever number of pixels they have. Pixels are
+// counted in a histogram, so memory doesn't grow with the number of frames. At
+// most opts.MaxPixels pixels are returned unless it is zero, which returns as
+// many pixels as the largest frame has.
+func (m Media) Pixels(ctx context.Context, opts Options) ([]color.ARGB, error) {
+	region := opts.Region
+	if m.Decoder.Crops {
+		region.Crop = Crop{}
+	}
+
+	histogram := map[color.ARGB]float64{}
+	size := 0
+	err := m.Decoder.Decode(ctx, m.Path, opts, func(img image.Image, weight float64) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		p, err := region.Pixels(img, opts.MaxPixels)
+		if err != nil {
+			return err
+		}
+		if len(p) == 0 || weight <= 0 {
+			return nil
+		}
+		size = max(size, len(p))
+		share := weight / float64(len(p))
+		for _, c := range p {
+			histogram[c] += share
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode %s with %s: %w", m.Mime, m.Decoder.Name, err)
+	}
+
+	if opts.MaxPixels > 0 {
+		size = min(size, opts.MaxPixels)
+	}
+	return expand(histogram, size), nil
+}
+
+// expand returns n pixels with every color of histogram repeated by its share
+// of the total weight. Colors are rounded so the counts add up to n.
+func expand(histogram map[color.ARGB]float64, n int) []color.ARGB {
+	colors := make([]color.ARGB, 0, len(histogram))
+	var total float64
+	for c, w := range histogram {
+		colors = append(colors, c)
+		total += w
+	}
+	if total <= 0 || n <= 0 {
+		return nil
+	}
+	slices.Sort(colors)
+
+	pixels := make([]color.ARGB, 0, n)
+	var sum float64
+	for _, c := range colors {
+		sum += histogram[c]
+		count := int(math.Round(sum/total*float64(n))) - len(pixels)
+		for range count {
+			pixels = append(pixels, c)
+		}
+	}
+	return pixels
+}
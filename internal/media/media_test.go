@@ -0,0 +1,155 @@
+package media
+
+import (
+	"context"
+	"image"
+	stdcolor "image/color"
+	"image/draw"
+	"maps"
+	"testing"
+
+	"github.com/Nadim147c/material/v3/color"
+	"github.com/Nadim147c/rong/v5/internal/config"
+	"github.com/Nadim147c/rong/v5/internal/config/enums"
+)
+
+func TestOptionsKey(t *testing.T) {
+	ctx := config.WithStore(t.Context(), config.New())
+	defaults, err := GetOptions(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	img := Media{Path: "a.png", Type: Type{Mime: "image/png", Kind: Image, Decoder: Native}}
+	video := Media{Path: "a.mkv", Type: Type{Mime: "video/x-matroska", Kind: Video, Decoder: FFmpeg}}
+	external := Media{Path: "a.heic", Type: Type{
+		Mime: "image/heic", Kind: Image,
+		Decoder: Decoder{Name: "external", Command: "heif-dec {in} -o {out}"},
+	}}
+
+	testdata := []struct {
+		name   string
+		media  Media
+		change func(o *Options)
+		want   string
+	}{
+		{"image defaults", img, func(*Options) {}, ""},
+		{"video defaults", video, func(*Options) {}, ""},
+		{"image ignores sampling", img, func(o *Options) { o.Frames = 10 }, ""},
+		{"image ignores frame size", img, func(o *Options) { o.FrameSize = 256 }, ""},
+		{"video frames", video, func(o *Options) { o.Frames = 10 }, "frames=10"},
+		{"video frame size", video, func(o *Options) { o.FrameSize = 256 }, "frame-size=256"},
+		{"video offset", video, func(o *Options) { o.Offset = 1.5 }, "offset=1.5"},
+		{"sampling", video, func(o *Options) { o.Sampling = enums.SamplingUniform }, "sample=uniform"},
+		{"window duration", video, func(o *Options) { o.Duration = 10 }, "duration=10"},
+		{"uniform ignores duration", video, func(o *Options) {
+			o.Sampling, o.Duration = enums.SamplingUniform, 10
+		}, "sample=uniform"},
+		{"scene threshold", video, func(o *Options) {
+			o.Sampling, o.SceneThreshold = enums.SamplingScene, 0.5
+		}, "sample=scene;scene-threshold=0.5"},
+		{"window ignores scene threshold", video, func(o *Options) { o.SceneThreshold = 0.5 }, ""},
+		{"max pixels", img, func(o *Options) { o.MaxPixels = 0 }, "max-pixels=0"},
+		{"external decoder", external, func(*Options) {}, "decoder=heif-dec {in} -o {out}"},
+		{"region", img, func(o *Options) { o.Region.TrimBorders = true }, "trim-borders"},
+		{"quantize", img, func(o *Options) { o.Quantize.MaxColors = 64 }, "max-colors=64"},
+		{"every part", video, func(o *Options) {
+			o.Region.CenterWeight = 2
+			o.Frames = 3
+			o.FrameSize = 512
+			o.MaxPixels = 1000
+			o.Quantize.MaxColors = 64
+		}, "center-weight=2;frames=3;frame-size=512;max-pixels=1000;max-colors=64"},
+	}
+
+	for _, test := range testdata {
+		t.Run(test.name, func(t *testing.T) {
+			opts := defaults
+			test.change(&opts)
+			if got := opts.Key(test.media); got != test.want {
+				t.Errorf("Key() = %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestExpand(t *testing.T) {
+	const (
+		red   color.ARGB = 0xffff0000
+		green color.ARGB = 0xff00ff00
+		blue  color.ARGB = 0xff0000ff
+	)
+
+	testdata := []struct {
+		name      string
+		histogram map[color.ARGB]float64
+		n         int
+		want      map[color.ARGB]int
+	}{
+		{"empty", nil, 10, map[color.ARGB]int{}},
+		{"no pixels", map[color.ARGB]float64{red: 1}, 0, map[color.ARGB]int{}},
+		{"single color", map[color.ARGB]float64{red: 0.3}, 4, map[color.ARGB]int{red: 4}},
+		{"shares", map[color.ARGB]float64{red: 3, green: 1}, 8, map[color.ARGB]int{red: 6, green: 2}},
+		{"rounded to n", map[color.ARGB]float64{red: 1, green: 1, blue: 1}, 10, map[color.ARGB]int{
+			red: 3, green: 4, blue: 3,
+		}},
+	}
+
+	for _, test := range testdata {
+		t.Run(test.name, func(t *testing.T) {
+			pixels := expand(test.histogram, test.n)
+			got := map[color.ARGB]int{}
+			for _, p := range pixels {
+				got[p]++
+			}
+			if !maps.Equal(got, test.want) {
+				t.Errorf("expand = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestPixelsWeights(t *testing.T) {
+	// A small frame standing for most of a video and a large one standing for
+	// the rest.
+	small := image.NewRGBA(image.Rect(0, 0, 2, 2))
+	large := image.NewRGBA(image.Rect(0, 0, 8, 8))
+	draw.Draw(small, small.Bounds(), image.NewUniform(stdcolor.RGBA{0xff, 0, 0, 0xff}), image.Point{}, draw.Src)
+	draw.Draw(large, large.Bounds(), image.NewUniform(stdcolor.RGBA{0, 0, 0xff, 0xff}), image.Point{}, draw.Src)
+
+	m := Media{Path: "a.mkv", Type: Type{Kind: Video, Decoder: Decoder{
+		Name: "test",
+		Decode: func(_ context.Context, _ string, _ Options, frame FrameFunc) error {
+			if err := frame(small, 0.75); err != nil {
+				return err
+			}
+			return frame(large, 0.25)
+		},
+	}}}
+
+	testdata := []struct {
+		name      string
+		maxPixels int
+		red, blue int
+	}{
+		{"unlimited", 0, 48, 16},
+		{"limited", 16, 12, 4},
+	}
+
+	for _, test := range testdata {
+		t.Run(test.name, func(t *testing.T) {
+			pixels, err := m.Pixels(t.Context(), Options{MaxPixels: test.maxPixels})
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := map[color.ARGB]int{}
+			for _, p := range pixels {
+				got[p]++
+			}
+			red, blue := got[color.ARGB(0xffff0000)], got[color.ARGB(0xff0000ff)]
+			if red != test.red || blue != test.blue {
+				t.Errorf("pixels have %d red and %d blue, want %d and %d", red, blue, test.red, test.blue)
+			}
+		})
+	}
+}
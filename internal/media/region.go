@@ -0,0 +1,270 @@
+package media
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	stdcolor "image/color"
+	"strconv"
+	"strings"
+
+	"github.com/Nadim147c/material/v3/color"
+	"github.com/Nadim147c/rong/v5/internal/material"
+)
+
+// ErrInvalidCrop means a crop is not in the x,y,w,h or percentage format.
+var ErrInvalidCrop = errors.New("invalid crop")
+
+// Crop is a rectangle of frames to use for colors. Every value is in pixels or
+// a percentage of the frame size.
+type Crop struct {
+	X, Y, W, H Length
+}
+
+// Length is a length in pixels or a percentage.
+type Length struct {
+	Value   float64
+	Percent bool
+}
+
+// of returns the length in pixels of a size.
+func (l Length) of(size int) int {
+	if l.Percent {
+		return int(l.Value * float64(size) / 100)
+	}
+	return int(l.Value)
+}
+
+func (l Length) String() string {
+	s := strconv.FormatFloat(l.Value, 'f', -1, 64)
+	if l.Percent {
+		s += "%"
+	}
+	return s
+}
+
+// ParseCrop parses a crop as "x,y,w,h" or a single percentage, which keeps the
+// center of frames (e.g. "80%"). An empty crop is the zero Crop.
+func ParseCrop(s string) (Crop, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return Crop{}, nil
+	}
+
+	fields := strings.Split(s, ",")
+	lengths := make([]Length, len(fields))
+	for i, field := range fields {
+		field = strings.TrimSpace(field)
+		l := Length{Percent: strings.HasSuffix(field, "%")}
+		v, err := strconv.ParseFloat(strings.TrimSuffix(field, "%"), 64)
+		if err != nil || v < 0 || (l.Percent && v > 100) {
+			return Crop{}, fmt.Errorf("%w: %q", ErrInvalidCrop, s)
+		}
+		l.Value = v
+		lengths[i] = l
+	}
+
+	switch {
+	case len(lengths) == 4:
+		return Crop{lengths[0], lengths[1], lengths[2], lengths[3]}, nil
+	case len(lengths) == 1 && lengths[0].Percent:
+		size := lengths[0]
+		offset := Length{(100 - size.Value) / 2, true}
+		return Crop{offset, offset, size, size}, nil
+	default:
+		return Crop{}, fmt.Errorf("%w: %q must be x,y,w,h or a percentage", ErrInvalidCrop, s)
+	}
+}
+
+// IsZero reports whether the crop keeps the whole frame.
+func (c Crop) IsZero() bool {
+	return c == Crop{}
+}
+
+func (c Crop) String() string {
+	if c.IsZero() {
+		return ""
+	}
+	return fmt.Sprintf("%s,%s,%s,%s", c.X, c.Y, c.W, c.H)
+}
+
+// Rect returns the rectangle of the crop in bounds.
+func (c Crop) Rect(bounds image.Rectangle) image.Rectangle {
+	if c.IsZero() {
+		return bounds
+	}
+	w, h := bounds.Dx(), bounds.Dy()
+	origin := bounds.Min.Add(image.Pt(c.X.of(w), c.Y.of(h)))
+	return image.Rectangle{origin, origin.Add(image.Pt(c.W.of(w), c.H.of(h)))}.Intersect(bounds)
+}
+
+// Filter returns an ffmpeg crop filter of the crop. Values in pixels are
+// clamped to the frame like Rect.
+func (c Crop) Filter() string {
+	if c.IsZero() {
+		return ""
+	}
+	expr := func(l Length, size string) string {
+		if l.Percent {
+			return fmt.Sprintf("%s*%s", size, formatFloat(l.Value/100))
+		}
+		return formatFloat(l.Value)
+	}
+	x, y := expr(c.X, "iw"), expr(c.Y, "ih")
+	return fmt.Sprintf("crop=w='min(%s,iw-%s)':h='min(%s,ih-%s)':x='%s':y='%s'",
+		expr(c.W, "iw"), x, expr(c.H, "ih"), y, x, y)
+}
+
+// Region selects the part of frames used for colors and how much its parts
+// count.
+type Region struct {
+	Crop Crop
+	// TrimBorders removes solid borders like letterboxes after cropping.
+	TrimBorders bool
+	// CenterWeight is how many times pixels in the center half of frames
+	// count. One or less counts every pixel the same.
+	CenterWeight int
+}
+
+// Key returns a string identifying the region, or an empty string when every
+// pixel of frames is used with the same weight.
+func (r Region) Key() string {
+	var parts []string
+	if !r.Crop.IsZero() {
+		parts = append(parts, "crop="+r.Crop.String())
+	}
+	if r.TrimBorders {
+		parts = append(parts, "trim-borders")
+	}
+	if r.CenterWeight > 1 {
+		parts = append(parts, "center-weight="+strconv.Itoa(r.CenterWeight))
+	}
+	return strings.Join(parts, ";")
+}
+
+type subImager interface {
+	SubImage(image.Rectangle) image.Image
+}
+
+// Pixels returns at most maxPixels pixels of the region of img, counting
+// center pixels CenterWeight times.
+func (r Region) Pixels(img image.Image, maxPixels int) ([]color.ARGB, error) {
+	bounds := r.Crop.Rect(img.Bounds())
+	if r.TrimBorders {
+		bounds = trimBorders(img, bounds)
+	}
+	if bounds.Empty() {
+		return nil, fmt.Errorf("%w: %s is outside of the %dx%d frame",
+			ErrInvalidCrop, r.Crop, img.Bounds().Dx(), img.Bounds().Dy())
+	}
+
+	if bounds == img.Bounds() && r.CenterWeight <= 1 {
+		return material.GetPixelsFromImage(img, maxPixels), nil
+	}
+
+	sub, ok := img.(subImager)
+	if !ok {
+		return nil, fmt.Errorf("%w: %T frames can't be cropped", ErrInvalidCrop, img)
+	}
+	img = sub.SubImage(bounds)
+
+	if r.CenterWeight <= 1 {
+		return material.GetPixelsFromImage(img, maxPixels), nil
+	}
+
+	// The center quarter of the area gets weight-1 extra samples, so the total
+	// stays within maxPixels.
+	extra := r.CenterWeight - 1
+	budget := maxPixels
+	if budget > 0 {
+		budget = max(1, budget*4/(4+extra))
+	}
+	pixels := material.GetPixelsFromImage(img, budget)
+
+	w, h := bounds.Dx(), bounds.Dy()
+	center := image.Rect(w/4, h/4, w-w/4, h-h/4).Add(bounds.Min)
+	centerPixels := material.GetPixelsFromImage(sub.SubImage(center), budget/4)
+	for range extra {
+		pixels = append(pixels, centerPixels...)
+	}
+	return pixels, nil
+}
+
+// Thresholds of channel differences to detect borders. Pixels of a border
+// differ less than borderTolerance from its color, and the first line after a
+// border has a pixel differing more than edgeContrast from it.
+const (
+	borderTolerance = 24
+	edgeContrast    = 48
+)
+
+// trimBorders returns bounds without solid borders at its edges, like
+// letterboxes. At most a third of each side is trimmed. Lines of a border must
+// have the same color and end at a clear edge, so flat images and gradients
+// are kept as is.
+func trimBorders(img image.Image, bounds image.Rectangle) image.Rectangle {
+	w, h := bounds.Dx(), bounds.Dy()
+	top := border(img, h, func(i int) image.Rectangle {
+		return image.Rect(bounds.Min.X, bounds.Min.Y+i, bounds.Max.X, bounds.Min.Y+i+1)
+	})
+	bottom := border(img, h, func(i int) image.Rectangle {
+		return image.Rect(bounds.Min.X, bounds.Max.Y-i-1, bounds.Max.X, bounds.Max.Y-i)
+	})
+	left := border(img, w, func(i int) image.Rectangle {
+		return image.Rect(bounds.Min.X+i, bounds.Min.Y, bounds.Min.X+i+1, bounds.Max.Y)
+	})
+	right := border(img, w, func(i int) image.Rectangle {
+		return image.Rect(bounds.Max.X-i-1, bounds.Min.Y, bounds.Max.X-i, bounds.Max.Y)
+	})
+
+	return image.Rect(
+		bounds.Min.X+left, bounds.Min.Y+top,
+		bounds.Max.X-right, bounds.Max.Y-bottom,
+	)
+}
+
+// border returns the number of lines of a solid border at an edge of n lines.
+// line returns the i-th line from the edge.
+func border(img image.Image, n int, line func(i int) image.Rectangle) int {
+	if n <= 0 {
+		return 0
+	}
+	edge := line(0)
+	ref := img.At(edge.Min.X, edge.Min.Y)
+
+	i := 0
+	for i < n/3 && !differs(img, line(i), ref, borderTolerance) {
+		i++
+	}
+	if i == 0 || !differs(img, line(i), ref, edgeContrast) {
+		return 0
+	}
+	return i
+}
+
+// differs reports whether one of up to 64 pixels evenly spread over a line
+// differs more than threshold from ref in a channel.
+func differs(img image.Image, line image.Rectangle, ref stdcolor.Color, threshold uint32) bool {
+	n := max(line.Dx(), line.Dy())
+	step := max(1, n/64)
+
+	rr, rg, rb, _ := ref.RGBA()
+	for i := 0; i < n; i += step {
+		x, y := line.Min.X, line.Min.Y
+		if line.Dx() > 1 {
+			x += i
+		} else {
+			y += i
+		}
+		r, g, b, _ := img.At(x, y).RGBA()
+		if diff(r, rr) > threshold || diff(g, rg) > threshold || diff(b, rb) > threshold {
+			return true
+		}
+	}
+	return false
+}
+
+// diff returns the difference of two 16 bit channels in 8 bits.
+func diff(a, b uint32) uint32 {
+	return (max(a, b) - min(a, b)) >> 8
+}
@@ -0,0 +1,102 @@
+package media
+
+import (
+	"errors"
+	"image"
+	"testing"
+)
+
+func TestParseCrop(t *testing.T) {
+	testdata := []struct {
+		name string
+		s    string
+		want string
+		err  bool
+	}{
+		{"empty", "", "", false},
+		{"pixels", "10,20,300,400", "10,20,300,400", false},
+		{"spaces", " 10, 20 ,300 , 400 ", "10,20,300,400", false},
+		{"percentages", "10%,0,50%,100%", "10%,0,50%,100%", false},
+		{"center", "80%", "10%,10%,80%,80%", false},
+		{"single pixel value", "80", "", true},
+		{"too few values", "1,2,3", "", true},
+		{"negative", "-1,0,10,10", "", true},
+		{"over 100 percent", "0,0,120%,10", "", true},
+		{"not a number", "a,0,10,10", "", true},
+	}
+
+	for _, test := range testdata {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := ParseCrop(test.s)
+			if test.err {
+				if !errors.Is(err, ErrInvalidCrop) {
+					t.Errorf("ParseCrop(%q) error = %v, want %v", test.s, err, ErrInvalidCrop)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.String() != test.want {
+				t.Errorf("ParseCrop(%q) = %q, want %q", test.s, got, test.want)
+			}
+		})
+	}
+}
+
+func TestCropRect(t *testing.T) {
+	bounds := image.Rect(0, 0, 200, 100)
+
+	testdata := []struct {
+		name string
+		crop string
+		want image.Rectangle
+	}{
+		{"none", "", bounds},
+		{"pixels", "10,20,30,40", image.Rect(10, 20, 40, 60)},
+		{"percentages", "50%,50%,50%,50%", image.Rect(100, 50, 200, 100)},
+		{"center", "50%", image.Rect(50, 25, 150, 75)},
+		{"clamped", "150,50,100,100", image.Rect(150, 50, 200, 100)},
+		{"outside", "300,0,10,10", image.Rectangle{}},
+	}
+
+	for _, test := range testdata {
+		t.Run(test.name, func(t *testing.T) {
+			crop, err := ParseCrop(test.crop)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := crop.Rect(bounds); !got.Eq(test.want) {
+				t.Errorf("Rect(%v) = %v, want %v", bounds, got, test.want)
+			}
+		})
+	}
+}
+
+func TestRegionKey(t *testing.T) {
+	crop, err := ParseCrop("80%")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testdata := []struct {
+		name   string
+		region Region
+		want   string
+	}{
+		{"zero", Region{}, ""},
+		{"center weight of one", Region{CenterWeight: 1}, ""},
+		{"crop", Region{Crop: crop}, "crop=10%,10%,80%,80%"},
+		{"trim borders", Region{TrimBorders: true}, "trim-borders"},
+		{"center weight", Region{CenterWeight: 3}, "center-weight=3"},
+		{"every part", Region{Crop: crop, TrimBorders: true, CenterWeight: 2}, "crop=10%,10%,80%,80%;trim-borders;center-weight=2"},
+	}
+
+	for _, test := range testdata {
+		t.Run(test.name, func(t *testing.T) {
+			if got := test.region.Key(); got != test.want {
+				t.Errorf("Key() = %q, want %q", got, test.want)
+			}
+		})
+	}
+}
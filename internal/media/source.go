@@ -0,0 +1,102 @@
+package media
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/Nadim147c/rong/v5/internal/pathutil"
+)
+
+// ErrInvalidWeight means the weight of a source is not a positive number.
+var ErrInvalidWeight = errors.New("invalid source weight")
+
+// Stdin is the source path of media read from standard input.
+const Stdin = "-"
+
+// ErrStdinTwice means standard input is used by more than one source.
+var ErrStdinTwice = errors.New("standard input can only be used once")
+
+// Source is a media file and the weight of its colors when several sources are
+// combined.
+type Source struct {
+	Path   string
+	Weight float64
+	// Stdin reports whether Path is a temporary copy of media read from
+	// standard input.
+	Stdin bool
+}
+
+// IsStdin reports whether a source argument reads standard input.
+func IsStdin(arg string) bool {
+	return arg == Stdin || strings.HasPrefix(arg, Stdin+":")
+}
+
+// ParseSource parses a source argument as "path" or "path:weight". Paths are
+// resolved from dir, except Stdin. A path that exists with its suffix is never
+// split, so files with a colon in their name keep working.
+func ParseSource(dir, arg string) (Source, error) {
+	src := Source{Path: arg, Weight: 1}
+
+	if IsStdin(arg) {
+		src.Path = Stdin
+		if weight, ok := strings.CutPrefix(arg, Stdin+":"); ok {
+			w, err := strconv.ParseFloat(weight, 64)
+			if err != nil || w <= 0 {
+				return src, fmt.Errorf("%w: %q in %q", ErrInvalidWeight, weight, arg)
+			}
+			src.Weight = w
+		}
+		return src, nil
+	}
+
+	if i := strings.LastIndexByte(arg, ':'); i > 0 {
+		path, weight := arg[:i], arg[i+1:]
+		full, err := pathutil.FindPath(dir, arg)
+		if _, statErr := os.Stat(full); err != nil || statErr != nil {
+			w, err := strconv.ParseFloat(weight, 64)
+			if err != nil || w <= 0 {
+				return src, fmt.Errorf("%w: %q in %q", ErrInvalidWeight, weight, arg)
+			}
+			src = Source{Path: path, Weight: w}
+		}
+	}
+
+	path, err := pathutil.FindPath(dir, src.Path)
+	if err != nil {
+		return src, fmt.Errorf("failed to find media path: %w", err)
+	}
+	src.Path = path
+	return src, nil
+}
+
+// Name returns the path of src shown in templates and the state. Media read
+// from standard input is only in a temporary file, so it is shown as Stdin.
+func (s Source) Name() string {
+	if s.Stdin {
+		return Stdin
+	}
+	return s.Path
+}
+
+// ParseSources parses every source argument. Only one of them can be Stdin.
+func ParseSources(dir string, args []string) ([]Source, error) {
+	sources := make([]Source, 0, len(args))
+	stdin := false
+	for _, arg := range args {
+		src, err := ParseSource(dir, arg)
+		if err != nil {
+			return nil, err
+		}
+		if src.Path == Stdin {
+			if stdin {
+				return nil, ErrStdinTwice
+			}
+			stdin = true
+		}
+		sources = append(sources, src)
+	}
+	return sources, nil
+}
@@ -0,0 +1,62 @@
+package media
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseSource(t *testing.T) {
+	dir := t.TempDir()
+	// A file with a colon in its name is never split.
+	if err := os.WriteFile(filepath.Join(dir, "a:2"), nil, 0o640); err != nil {
+		t.Fatal(err)
+	}
+
+	testdata := []struct {
+		name   string
+		arg    string
+		path   string
+		weight float64
+		err    error
+	}{
+		{"path", "a.png", filepath.Join(dir, "a.png"), 1, nil},
+		{"weight", "a.png:2", filepath.Join(dir, "a.png"), 2, nil},
+		{"fractional weight", "a.png:0.5", filepath.Join(dir, "a.png"), 0.5, nil},
+		{"absolute path", "/tmp/a.png:3", "/tmp/a.png", 3, nil},
+		{"existing path with colon", "a:2", filepath.Join(dir, "a:2"), 1, nil},
+		{"existing path with colon and weight", "a:2:4", filepath.Join(dir, "a:2"), 4, nil},
+		{"stdin", "-", Stdin, 1, nil},
+		{"stdin weight", "-:2", Stdin, 2, nil},
+		{"zero weight", "a.png:0", "", 0, ErrInvalidWeight},
+		{"negative weight", "a.png:-1", "", 0, ErrInvalidWeight},
+		{"invalid weight", "a.png:x", "", 0, ErrInvalidWeight},
+		{"invalid stdin weight", "-:x", "", 0, ErrInvalidWeight},
+	}
+
+	for _, test := range testdata {
+		t.Run(test.name, func(t *testing.T) {
+			src, err := ParseSource(dir, test.arg)
+			if test.err != nil {
+				if !errors.Is(err, test.err) {
+					t.Errorf("ParseSource(%q) error = %v, want %v", test.arg, err, test.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if src.Path != test.path || src.Weight != test.weight {
+				t.Errorf("ParseSource(%q) = %q:%v, want %q:%v",
+					test.arg, src.Path, src.Weight, test.path, test.weight)
+			}
+		})
+	}
+}
+
+func TestParseSourcesStdinTwice(t *testing.T) {
+	if _, err := ParseSources(t.TempDir(), []string{"-", "a.png", "-:2"}); !errors.Is(err, ErrStdinTwice) {
+		t.Errorf("ParseSources error = %v, want %v", err, ErrStdinTwice)
+	}
+}
@@ -0,0 +1,99 @@
+package media
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/gabriel-vasile/mimetype"
+	"github.com/google/renameio/v2"
+)
+
+// ErrEmptyStdin means standard input has no media.
+var ErrEmptyStdin = errors.New("standard input is empty")
+
+// sniffSize is the number of bytes read to detect the media type of a stream.
+const sniffSize = 3072
+
+// Spool copies the media read from r to the file name in dir. The extension
+// of the media type sniffed from the stream is added to name, so decoders
+// matching extensions keep working. The media is written to a temporary file
+// in dir that replaces the file once the stream has been read entirely. The
+// path of the file is returned.
+func Spool(r io.Reader, dir, name string) (string, error) {
+	br := bufio.NewReaderSize(r, sniffSize)
+	head, err := br.Peek(sniffSize)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return "", fmt.Errorf("failed to read standard input: %w", err)
+	}
+	if len(head) == 0 {
+		return "", ErrEmptyStdin
+	}
+
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return "", err
+	}
+
+	path := filepath.Join(dir, name+mimetype.Detect(head).Extension())
+	file, err := renameio.NewPendingFile(
+		path,
+		renameio.WithTempDir(dir),
+		renameio.WithPermissions(0o640),
+	)
+	if err != nil {
+		return "", err
+	}
+	defer file.Cleanup()
+
+	if _, err := io.Copy(file, br); err != nil {
+		return "", fmt.Errorf("failed to read standard input: %w", err)
+	}
+	return path, file.CloseAtomicallyReplace()
+}
+
+// ReadStdin replaces the Stdin source of sources with a file holding the media
+// read from r. The file is the source file in dir when dir is not empty, and a
+// temporary file removed by cleanup otherwise.
+func ReadStdin(r io.Reader, sources []Source, dir string) (cleanup func(), err error) {
+	cleanup = func() {}
+
+	i := -1
+	for j, src := range sources {
+		if src.Path == Stdin {
+			i = j
+		}
+	}
+	if i < 0 {
+		return cleanup, nil
+	}
+
+	temp := dir == ""
+	if temp {
+		dir, err = os.MkdirTemp("", "rong-stdin-*")
+		if err != nil {
+			return cleanup, err
+		}
+		cleanup = func() { _ = os.RemoveAll(dir) }
+	}
+
+	path, err := Spool(r, dir, "source")
+	if err != nil {
+		cleanup()
+		return func() {}, err
+	}
+	if !temp {
+		// Sources read before may have another extension. They are kept until
+		// the new source is in place, so a failed read doesn't lose them.
+		old, _ := filepath.Glob(filepath.Join(dir, "source.*"))
+		for _, p := range old {
+			if p != path {
+				_ = os.Remove(p)
+			}
+		}
+	}
+	sources[i] = Source{Path: path, Weight: sources[i].Weight, Stdin: temp}
+	return cleanup, nil
+}
@@ -0,0 +1,87 @@
+package media
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// png is the start of a png image, enough for its type to be sniffed.
+const png = "\x89PNG\r\n\x1a\n"
+
+func TestReadStdinSaveSource(t *testing.T) {
+	testdata := []struct {
+		name  string
+		input string
+		err   error
+		// files are the files left in the directory.
+		files []string
+	}{
+		{"replaces sources of other types", png + "new", nil, []string{"source.png"}},
+		{"keeps sources after a failed read", "", ErrEmptyStdin, []string{"source.gif", "source.png"}},
+	}
+
+	for _, test := range testdata {
+		t.Run(test.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for _, name := range []string{"source.gif", "source.png"} {
+				if err := os.WriteFile(filepath.Join(dir, name), []byte("old"), 0o640); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			sources := []Source{{Path: Stdin, Weight: 2}}
+			cleanup, err := ReadStdin(strings.NewReader(test.input), sources, dir)
+			cleanup()
+			if !errors.Is(err, test.err) {
+				t.Fatalf("ReadStdin error = %v, want %v", err, test.err)
+			}
+
+			entries, err := os.ReadDir(dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var files []string
+			for _, entry := range entries {
+				files = append(files, entry.Name())
+			}
+			if !slices.Equal(files, test.files) {
+				t.Errorf("files = %v, want %v", files, test.files)
+			}
+
+			if test.err != nil {
+				return
+			}
+			want := Source{Path: filepath.Join(dir, "source.png"), Weight: 2}
+			if sources[0] != want {
+				t.Errorf("source = %+v, want %+v", sources[0], want)
+			}
+			if data, err := os.ReadFile(want.Path); err != nil || string(data) != png+"new" {
+				t.Errorf("source holds %q, %v", data, err)
+			}
+		})
+	}
+}
+
+func TestReadStdinTemp(t *testing.T) {
+	sources := []Source{{Path: "a.png", Weight: 1}, {Path: Stdin, Weight: 1}}
+	cleanup, err := ReadStdin(strings.NewReader(png), sources, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	src := sources[1]
+	if !src.Stdin || filepath.Base(src.Path) != "source.png" {
+		t.Errorf("source = %+v, want a temporary stdin source", src)
+	}
+	if _, err := os.Stat(src.Path); err != nil {
+		t.Fatal(err)
+	}
+	cleanup()
+	if _, err := os.Stat(filepath.Dir(src.Path)); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("temporary directory left after cleanup: %v", err)
+	}
+}
@@ -132,9 +132,9 @@ func NewMaterial(
 	for name, col := range customColors {
 		name := toCamelCase(strings.ToLower(name), true)
 		custom[name] = NewFormatedColor(col.Color)
-		custom["On"+name] = NewFormatedColor(col.Color)
-		custom[name+"Container"] = NewFormatedColor(col.Color)
-		custom["On"+name+"Container"] = NewFormatedColor(col.Color)
+		custom["On"+name] = NewFormatedColor(col.OnColor)
+		custom[name+"Container"] = NewFormatedColor(col.ColorContainer)
+		custom["On"+name+"Container"] = NewFormatedColor(col.OnColorContainer)
 	}
 
 	return Material{
@@ -1,6 +1,7 @@
 package models
 
 import (
+	"context"
 	"fmt"
 	"slices"
 	"strings"
@@ -23,6 +24,7 @@ type Output struct {
 
 // NewOutput create output struct for templates execution.
 func NewOutput(
+	ctx context.Context,
 	source string,
 	base16Colors base16.Base16,
 	materialColors map[string]color.ARGB,
@@ -43,7 +45,7 @@ func NewOutput(
 			NewNamedColor(key, value.Color),
 			NewNamedColor("on_"+key, value.OnColor),
 			NewNamedColor(key+"_container", value.ColorContainer),
-			NewNamedColor("on_"+key+"_container", value.OnColor),
+			NewNamedColor("on_"+key+"_container", value.OnColorContainer),
 		)
 	}
 
@@ -52,7 +54,7 @@ func NewOutput(
 	})
 
 	m := NewMaterial(materialColors, customColors)
-	dark := config.Dark.Value()
+	dark := config.Dark.Value(ctx)
 
 	return Output{
 		Material: m,
@@ -156,6 +158,13 @@ func (cv FormatedColor) String() string {
 
 // NewNamedColor creates a Color.
 func NewNamedColor(key string, rgb color.ARGB) NamedColor {
+	value := NewFormatedColor(rgb)
+	return NamedColor{Name: NewColorName(key), Color: value}
+}
+
+// NewColorName returns the case variations of the color key, like the name of
+// a material role or custom color.
+func NewColorName(key string) ColorName {
 	// Convert snake_case to other cases
 	var name ColorName
 	key = strings.ToLower(key)
@@ -163,9 +172,7 @@ func NewNamedColor(key string, rgb color.ARGB) NamedColor {
 	name.Camel = toCamelCase(key, false)
 	name.Kebab = strings.ReplaceAll(key, "_", "-")
 	name.Pascal = toCamelCase(key, true)
-
-	value := NewFormatedColor(rgb)
-	return NamedColor{Name: name, Color: value}
+	return name
 }
 
 func lf(c uint8) float64 { return float64(c) / 255.0 }
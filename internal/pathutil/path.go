@@ -19,6 +19,8 @@ var (
 	CacheDir = filepath.Clean(filepath.Join(xdg.CacheHome, app))
 	// StateDir is the rong state directory.
 	StateDir = filepath.Clean(filepath.Join(xdg.StateHome, app))
+	// RuntimeDir is the rong runtime directory.
+	RuntimeDir = filepath.Clean(filepath.Join(xdg.RuntimeDir, app))
 )
 
 // ErrEmptyPath means user difined an empty string as path.
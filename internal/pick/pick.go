@@ -0,0 +1,184 @@
+// Package pick lets users pick the source color of a theme from scored colors
+// in a terminal.
+package pick
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/Nadim147c/material/v3/color"
+	"github.com/Nadim147c/rong/v5/internal/config"
+	"github.com/Nadim147c/rong/v5/internal/material"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss/v2"
+	"github.com/charmbracelet/x/term"
+)
+
+// ErrNoTerminal means colors can't be picked without a terminal.
+var ErrNoTerminal = errors.New("picking a source color needs a terminal")
+
+// limit is the number of candidates shown. Every candidate has a number key.
+const limit = 9
+
+type interactiveKey struct{}
+
+// Interactive returns a copy of ctx in which the pick option is honored. It is
+// used by commands run by the user, so watching, rotating and the daemon never
+// wait for a pick.
+func Interactive(ctx context.Context) context.Context {
+	return context.WithValue(ctx, interactiveKey{}, true)
+}
+
+// Source returns the source color of quantized colors for generation. It is
+// the source-color option, or the color picked by the user when the pick
+// option is set and ctx is interactive. A zero color lets generation use the
+// scored color.
+func Source(ctx context.Context, quantized material.Quantized, cfg material.Config) (color.ARGB, error) {
+	if !config.Pick.Value(ctx) {
+		return config.SourceColor.Value(ctx), nil
+	}
+	if interactive, _ := ctx.Value(interactiveKey{}).(bool); !interactive {
+		slog.Debug("Ignoring pick outside of interactive commands")
+		return config.SourceColor.Value(ctx), nil
+	}
+	return Pick(ctx, quantized, cfg)
+}
+
+// Pick shows the scored candidates of quantized colors with a preview of the
+// scheme of each and returns the one picked by the user. Keys are read from
+// the controlling terminal, so media can still be piped to standard input.
+func Pick(ctx context.Context, quantized material.Quantized, cfg material.Config) (color.ARGB, error) {
+	tty, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %w", ErrNoTerminal, err)
+	}
+	defer tty.Close()
+
+	// The picker is drawn on stderr unless it is redirected.
+	var output io.Writer = tty
+	if term.IsTerminal(os.Stderr.Fd()) {
+		output = os.Stderr
+	}
+
+	scored := material.Candidates(quantized, limit)
+	if len(scored) == 0 {
+		return 0, material.ErrNoColorFound
+	}
+
+	m := model{cursor: max(min(cfg.SourceRank, len(scored))-1, 0)}
+	for _, source := range scored {
+		colors, err := material.GenerateFromQuantized(quantized, cfg, source)
+		if err != nil {
+			return 0, err
+		}
+		m.candidates = append(m.candidates, candidate{source, colors})
+	}
+
+	p := tea.NewProgram(m, tea.WithContext(ctx), tea.WithInput(tty), tea.WithOutput(output))
+	final, err := p.Run()
+	if err != nil {
+		if ctx.Err() != nil {
+			return 0, ctx.Err()
+		}
+		return 0, fmt.Errorf("failed to run color picker: %w", err)
+	}
+
+	m = final.(model)
+	if !m.picked {
+		return 0, context.Canceled
+	}
+	return m.candidates[m.cursor].source, nil
+}
+
+// candidate is a source color and the scheme generated from it.
+type candidate struct {
+	source color.ARGB
+	colors material.Colors
+}
+
+type model struct {
+	candidates []candidate
+	cursor     int
+	picked     bool
+	done       bool
+}
+
+func (m model) Init() tea.Cmd {
+	return nil
+}
+
+func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	key, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+
+	switch s := key.String(); s {
+	case "ctrl+c", "esc", "q":
+		m.done = true
+		return m, tea.Quit
+	case "enter", " ":
+		m.picked, m.done = true, true
+		return m, tea.Quit
+	case "up", "k", "shift+tab":
+		m.cursor = (m.cursor + len(m.candidates) - 1) % len(m.candidates)
+	case "down", "j", "tab":
+		m.cursor = (m.cursor + 1) % len(m.candidates)
+	default:
+		if len(s) == 1 && s[0] >= '1' && int(s[0]-'0') <= len(m.candidates) {
+			m.cursor = int(s[0] - '1')
+		}
+	}
+	return m, nil
+}
+
+// swatch renders text on the background of c.
+func swatch(text string, bg, fg color.ARGB) string {
+	return lipgloss.NewStyle().
+		Background(lipgloss.Color(bg.HexRGB())).
+		Foreground(lipgloss.Color(fg.HexRGB())).
+		Render(text)
+}
+
+// roles are the colors previewed for every candidate and the colors of text on
+// them.
+var roles = [][2]string{
+	{"primary", "on_primary"},
+	{"secondary", "on_secondary"},
+	{"tertiary", "on_tertiary"},
+	{"surface", "on_surface"},
+}
+
+func (m model) View() string {
+	if m.done {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteString("Pick a source color\n\n")
+	for i, c := range m.candidates {
+		cursor := "  "
+		if i == m.cursor {
+			cursor = "> "
+		}
+		fmt.Fprintf(&b, "%s%d %s %s ", cursor, i+1, swatch("      ", c.source, c.source), c.source.HexRGB())
+		for _, role := range roles {
+			b.WriteString(swatch("   ", c.colors[role[0]], c.colors[role[1]]))
+		}
+		b.WriteByte('\n')
+	}
+
+	c := m.candidates[m.cursor]
+	b.WriteByte('\n')
+	for _, role := range roles {
+		name := fmt.Sprintf(" %-9s ", strings.ToUpper(role[0][:1])+role[0][1:])
+		b.WriteString(swatch(name, c.colors[role[0]], c.colors[role[1]]))
+	}
+	b.WriteString("\n\n↑/↓ or 1-9 move • enter pick • q cancel\n")
+	return b.String()
+}
@@ -0,0 +1,23 @@
+package pick
+
+import (
+	"testing"
+
+	"github.com/Nadim147c/material/v3/color"
+	"github.com/Nadim147c/rong/v5/internal/config"
+	"github.com/Nadim147c/rong/v5/internal/material"
+)
+
+func TestSourceNotInteractive(t *testing.T) {
+	const source color.ARGB = 0xffcc3333
+
+	store := config.New()
+	store.Set(config.Pick.Key(), true)
+	store.Set(config.SourceColor.Key(), source.HexRGB())
+	ctx := config.WithStore(t.Context(), store)
+
+	got, err := Source(ctx, material.Quantized{}, material.Config{})
+	if err != nil || got != source {
+		t.Errorf("Source = %v, %v, want %v", got, err, source)
+	}
+}
@@ -0,0 +1,159 @@
+// Package ppm reads streams of binary ppm and raw rgb24 images as written by
+// ffmpeg and other decoders.
+package ppm
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"image"
+	"io"
+	"strconv"
+)
+
+// ErrInvalid means the stream is not a valid ppm image.
+var ErrInvalid = errors.New("invalid ppm image")
+
+// Reader reads images from a stream. The image returned by Next and NextRaw is
+// reused for the next image of the same size, also after Reset.
+type Reader struct {
+	r   *bufio.Reader
+	img *image.RGBA
+	row []byte
+}
+
+// NewReader returns a Reader reading from r.
+func NewReader(r io.Reader) *Reader {
+	return &Reader{r: bufio.NewReader(r)}
+}
+
+// Reset makes the reader read from r, keeping its buffers.
+func (r *Reader) Reset(rd io.Reader) {
+	if r.r == nil {
+		r.r = bufio.NewReader(rd)
+		return
+	}
+	r.r.Reset(rd)
+}
+
+// Next reads a binary ppm image. Samples of images whose maxval isn't 255,
+// including 16 bit samples, are scaled to 8 bits. It returns io.EOF when there
+// are no more images.
+func (r *Reader) Next() (*image.RGBA, error) {
+	magic, err := r.field()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, io.EOF
+		}
+		return nil, fmt.Errorf("%w: bad header: %w", ErrInvalid, err)
+	}
+	if magic != "P6" {
+		return nil, fmt.Errorf("%w: bad magic number %q", ErrInvalid, magic)
+	}
+
+	var header [3]int
+	for i := range header {
+		field, err := r.field()
+		if errors.Is(err, io.EOF) {
+			err = io.ErrUnexpectedEOF
+		}
+		if err == nil {
+			header[i], err = strconv.Atoi(field)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: bad header: %w", ErrInvalid, err)
+		}
+	}
+
+	width, height, maxval := header[0], header[1], header[2]
+	if width <= 0 || height <= 0 || maxval <= 0 || maxval > 0xffff {
+		return nil, fmt.Errorf("%w: bad header", ErrInvalid)
+	}
+	return r.read(width, height, maxval)
+}
+
+// NextRaw reads a raw rgb24 image of width by height pixels. It returns io.EOF
+// when there are no more images.
+func (r *Reader) NextRaw(width, height int) (*image.RGBA, error) {
+	if width <= 0 || height <= 0 {
+		return nil, fmt.Errorf("invalid rgb24 image size %dx%d", width, height)
+	}
+	if _, err := r.r.Peek(1); err != nil {
+		return nil, err
+	}
+	return r.read(width, height, 0xff)
+}
+
+// read reads the pixels of an image of width by height pixels with samples up
+// to maxval. Samples take two bytes when maxval is above 255.
+func (r *Reader) read(width, height, maxval int) (*image.RGBA, error) {
+	size := 1
+	if maxval > 0xff {
+		size = 2
+	}
+	if r.img == nil || r.img.Rect.Dx() != width || r.img.Rect.Dy() != height {
+		r.img = image.NewRGBA(image.Rect(0, 0, width, height))
+	}
+	if len(r.row) != width*3*size {
+		r.row = make([]byte, width*3*size)
+	}
+	for y := range height {
+		if _, err := io.ReadFull(r.r, r.row); err != nil {
+			if errors.Is(err, io.EOF) {
+				err = io.ErrUnexpectedEOF
+			}
+			return nil, fmt.Errorf("failed to read %dx%d image: %w", width, height, err)
+		}
+		pix := r.img.Pix[y*r.img.Stride:]
+		for x := range width {
+			for c := range 3 {
+				i := (x*3 + c) * size
+				v := int(r.row[i])
+				if size == 2 {
+					v = v<<8 | int(r.row[i+1])
+				}
+				if maxval != 0xff {
+					v = (min(v, maxval)*0xff + maxval/2) / maxval
+				}
+				pix[x*4+c] = uint8(v)
+			}
+			pix[x*4+3] = 0xff
+		}
+	}
+	return r.img, nil
+}
+
+// field reads a header field of a ppm image. Comments are skipped, and the
+// single whitespace after the last field is consumed.
+func (r *Reader) field() (string, error) {
+	var field []byte
+	for {
+		b, err := r.r.ReadByte()
+		if err != nil {
+			if len(field) > 0 && errors.Is(err, io.EOF) {
+				break
+			}
+			return "", err
+		}
+		if b == '#' {
+			if _, err := r.r.ReadString('\n'); err != nil {
+				return "", err
+			}
+			if len(field) > 0 {
+				break
+			}
+			continue
+		}
+		if b == ' ' || b == '\t' || b == '\n' || b == '\r' {
+			if len(field) > 0 {
+				break
+			}
+			continue
+		}
+		if len(field) == 16 {
+			return "", errors.New("field is too long")
+		}
+		field = append(field, b)
+	}
+	return string(field), nil
+}
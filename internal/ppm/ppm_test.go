@@ -0,0 +1,83 @@
+package ppm
+
+import (
+	"errors"
+	"io"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestNext(t *testing.T) {
+	testdata := []struct {
+		name  string
+		input string
+		// want is the rgb of the pixels of every image.
+		want [][]byte
+		err  error
+	}{
+		{"single image", "P6\n2 1\n255\n\x01\x02\x03\x04\x05\x06", [][]byte{{1, 2, 3, 4, 5, 6}}, nil},
+		{"stream", "P6 1 1 255 \x01\x02\x03P6 1 1 255 \x04\x05\x06", [][]byte{{1, 2, 3}, {4, 5, 6}}, nil},
+		{"comments", "P6 # made by a camera\n1 # width\n1\n#maxval\n255\n\x01\x02\x03", [][]byte{{1, 2, 3}}, nil},
+		{"comment after a field", "P6#comment\n1 1 255#comment\n\x01\x02\x03", [][]byte{{1, 2, 3}}, nil},
+		{"maxval below 255", "P6 1 1 15 \x00\x0f\x05", [][]byte{{0, 255, 85}}, nil},
+		{"maxval above 255", "P6 1 1 65535 \x00\x00\xff\xff\x80\x00", [][]byte{{0, 255, 128}}, nil},
+		{"maxval 1023", "P6 1 1 1023 \x00\x00\x03\xff\x02\x00", [][]byte{{0, 255, 128}}, nil},
+		{"samples above maxval", "P6 1 1 15 \xff\x00\x00", [][]byte{{255, 0, 0}}, nil},
+		{"empty", "", nil, nil},
+		{"ascii ppm", "P3 1 1 255 1 2 3", nil, ErrInvalid},
+		{"maxval too large", "P6 1 1 65536 \x00\x00\x00", nil, ErrInvalid},
+		{"zero width", "P6 0 1 255 ", nil, ErrInvalid},
+		{"not a number", "P6 a 1 255 \x00\x00\x00", nil, ErrInvalid},
+		{"truncated header", "P6 1 1", nil, io.ErrUnexpectedEOF},
+		{"truncated pixels", "P6 2 1 255 \x01\x02\x03", nil, io.ErrUnexpectedEOF},
+		{"truncated 16 bit pixels", "P6 1 1 65535 \x00\x00\x00\x00\x00", nil, io.ErrUnexpectedEOF},
+	}
+
+	for _, test := range testdata {
+		t.Run(test.name, func(t *testing.T) {
+			r := NewReader(strings.NewReader(test.input))
+			var got [][]byte
+			for {
+				img, err := r.Next()
+				if errors.Is(err, io.EOF) {
+					break
+				}
+				if err != nil {
+					if test.err == nil || !errors.Is(err, test.err) {
+						t.Fatalf("Next error = %v, want %v", err, test.err)
+					}
+					return
+				}
+				var rgb []byte
+				for i := 0; i < len(img.Pix); i += 4 {
+					rgb = append(rgb, img.Pix[i:i+3]...)
+					if img.Pix[i+3] != 0xff {
+						t.Errorf("alpha = %d, want 255", img.Pix[i+3])
+					}
+				}
+				got = append(got, rgb)
+			}
+			if test.err != nil {
+				t.Fatalf("Next error = nil, want %v", test.err)
+			}
+			if !slices.EqualFunc(got, test.want, slices.Equal) {
+				t.Errorf("Next = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestNextRaw(t *testing.T) {
+	r := NewReader(strings.NewReader("\x01\x02\x03\x04\x05\x06\x07\x08\x09"))
+	img, err := r.NextRaw(1, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []byte{1, 2, 3, 0xff, 4, 5, 6, 0xff}; !slices.Equal(img.Pix, want) {
+		t.Errorf("NextRaw = %v, want %v", img.Pix, want)
+	}
+	if _, err := r.NextRaw(1, 2); !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("NextRaw of a truncated image error = %v, want %v", err, io.ErrUnexpectedEOF)
+	}
+}
@@ -0,0 +1,262 @@
+// Package scan finds image and video files in directories.
+package scan
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/Nadim147c/rong/v5/internal/config"
+	"github.com/Nadim147c/rong/v5/internal/media"
+)
+
+// ignoreFile contains glob patterns of files to skip in its directory.
+const ignoreFile = ".rongignore"
+
+// scanner walks directories and sends media files to paths.
+type scanner struct {
+	ctx      context.Context
+	paths    chan<- string
+	exclude  []string
+	follow   bool
+	maxDepth int
+	hidden   bool
+
+	// visited contains resolved paths of walked directories.
+	visited map[string]bool
+	// ignores contains patterns of .rongignore files by directory.
+	ignores map[string][]string
+	errs    []error
+}
+
+// Find scans the given paths and sends absolute paths of image/video files to
+// paths. Directories are walked recursively in lexical order. Paths that can't
+// be read are logged and skipped, and returned joined after the walk.
+func Find(ctx context.Context, inputs []string, paths chan<- string) error {
+	s := &scanner{
+		ctx:      ctx,
+		paths:    paths,
+		exclude:  config.Exclude.Value(ctx),
+		follow:   config.FollowSymlinks.Value(ctx),
+		maxDepth: config.MaxDepth.Value(ctx),
+		hidden:   config.Hidden.Value(ctx),
+		visited:  map[string]bool{},
+		ignores:  map[string][]string{},
+	}
+
+	for _, pattern := range s.exclude {
+		if _, err := filepath.Match(strings.Trim(pattern, "/"), ""); err != nil {
+			return fmt.Errorf("invalid exclude pattern %q: %w", pattern, err)
+		}
+	}
+
+	for p := range slices.Values(inputs) {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		abs, err := filepath.Abs(p)
+		if err != nil {
+			s.fail(p, err)
+			continue
+		}
+
+		fileInfo, err := os.Stat(abs)
+		if err != nil {
+			s.fail(abs, err)
+			continue
+		}
+
+		if fileInfo.IsDir() {
+			if err := s.walk(abs, abs, 0); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if media.IsMedia(ctx, abs) {
+			if err := s.send(abs); err != nil {
+				return err
+			}
+		}
+	}
+
+	return errors.Join(s.errs...)
+}
+
+// fail records an error of a path and continues the walk.
+func (s *scanner) fail(path string, err error) {
+	slog.Warn("Failed to scan path", "path", path, "error", err)
+	var pathErr *fs.PathError
+	if !errors.As(err, &pathErr) {
+		err = &fs.PathError{Op: "scan", Path: path, Err: err}
+	}
+	s.errs = append(s.errs, err)
+}
+
+func (s *scanner) send(path string) error {
+	select {
+	case s.paths <- path:
+		return nil
+	case <-s.ctx.Done():
+		return s.ctx.Err()
+	}
+}
+
+// walk walks dir, which is depth levels below the scanned root.
+func (s *scanner) walk(root, dir string, depth int) error {
+	// A trailing separator makes WalkDir resolve dir if it is a symlink.
+	return filepath.WalkDir(dir+string(filepath.Separator), func(path string, d fs.DirEntry, err error) error {
+		if ctxErr := s.ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
+
+		path = filepath.Clean(path)
+		if err != nil {
+			s.fail(path, err)
+			return nil
+		}
+
+		rel, _ := filepath.Rel(dir, path)
+		level := depth
+		if rel != "." {
+			level += strings.Count(rel, string(filepath.Separator)) + 1
+		}
+
+		if path != dir {
+			if !s.hidden && strings.HasPrefix(d.Name(), ".") {
+				return skip(d)
+			}
+
+			isDir := d.IsDir()
+			if d.Type()&fs.ModeSymlink != 0 {
+				info, err := os.Stat(path)
+				if err != nil {
+					s.fail(path, err)
+					return nil
+				}
+				isDir = info.IsDir()
+			}
+
+			if s.ignored(root, path, isDir) {
+				return skip(d)
+			}
+
+			if isDir && d.Type()&fs.ModeSymlink != 0 {
+				if !s.follow || (s.maxDepth > 0 && level >= s.maxDepth) {
+					return nil
+				}
+				return s.walk(root, path, level)
+			}
+		}
+
+		if d.IsDir() {
+			if s.maxDepth > 0 && level >= s.maxDepth && path != dir {
+				return fs.SkipDir
+			}
+
+			real, err := filepath.EvalSymlinks(path)
+			if err != nil {
+				s.fail(path, err)
+				return fs.SkipDir
+			}
+			if s.visited[real] {
+				slog.Debug("Skipping already scanned directory", "path", path, "target", real)
+				return fs.SkipDir
+			}
+			s.visited[real] = true
+
+			s.loadIgnore(path)
+			return nil
+		}
+
+		if s.maxDepth > 0 && level > s.maxDepth {
+			return nil
+		}
+
+		if media.IsMedia(s.ctx, path) {
+			return s.send(path)
+		}
+		return nil
+	})
+}
+
+// skip skips d and its content.
+func skip(d fs.DirEntry) error {
+	if d.IsDir() {
+		return fs.SkipDir
+	}
+	return nil
+}
+
+// loadIgnore reads the .rongignore file of dir.
+func (s *scanner) loadIgnore(dir string) {
+	file, err := os.Open(filepath.Join(dir, ignoreFile))
+	if err != nil {
+		if !errors.Is(err, fs.ErrNotExist) {
+			s.fail(filepath.Join(dir, ignoreFile), err)
+		}
+		return
+	}
+	defer file.Close()
+
+	var patterns []string
+	lines := bufio.NewScanner(file)
+	for lines.Scan() {
+		line := strings.TrimSpace(lines.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		patterns = append(patterns, line)
+	}
+	s.ignores[dir] = patterns
+}
+
+// ignored reports whether path matches an exclude pattern or a pattern of a
+// .rongignore file in one of its parent directories up to root.
+func (s *scanner) ignored(root, path string, isDir bool) bool {
+	if rel, err := filepath.Rel(root, path); err == nil && matchAny(s.exclude, rel, isDir) {
+		return true
+	}
+
+	for dir := filepath.Dir(path); ; dir = filepath.Dir(dir) {
+		if rel, err := filepath.Rel(dir, path); err == nil && matchAny(s.ignores[dir], rel, isDir) {
+			return true
+		}
+		if dir == root || dir == filepath.Dir(dir) {
+			return false
+		}
+	}
+}
+
+// matchAny reports whether rel matches one of the patterns. Patterns without a
+// separator match the name at any depth, others match from the start of rel.
+// A trailing separator only matches directories.
+func matchAny(patterns []string, rel string, isDir bool) bool {
+	rel = filepath.ToSlash(rel)
+	for _, pattern := range patterns {
+		if strings.HasSuffix(pattern, "/") && !isDir {
+			continue
+		}
+		pattern = strings.TrimSuffix(pattern, "/")
+
+		name := rel
+		if strings.Contains(pattern, "/") {
+			pattern = strings.TrimPrefix(pattern, "/")
+		} else {
+			name = rel[strings.LastIndex(rel, "/")+1:]
+		}
+
+		if ok, _ := filepath.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,149 @@
+package scan
+
+import (
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/Nadim147c/rong/v5/internal/config"
+)
+
+func TestMatchAny(t *testing.T) {
+	testdata := []struct {
+		name     string
+		patterns []string
+		rel      string
+		isDir    bool
+		want     bool
+	}{
+		{"no patterns", nil, "a.png", false, false},
+		{"name", []string{"a.png"}, "a.png", false, true},
+		{"name glob", []string{"*.png"}, "a.png", false, true},
+		{"name at depth", []string{"*.png"}, "x/y/a.png", false, true},
+		{"other name", []string{"*.jpg"}, "x/a.png", false, false},
+		{"path from root", []string{"x/*.png"}, "x/a.png", false, true},
+		{"path not at root", []string{"x/*.png"}, "y/x/a.png", false, false},
+		{"leading separator", []string{"/x"}, "x", true, true},
+		{"directory pattern", []string{"drafts/"}, "drafts", true, true},
+		{"directory pattern on file", []string{"drafts/"}, "drafts", false, false},
+		{"directory pattern at depth", []string{"drafts/"}, "x/drafts", true, true},
+		{"any of patterns", []string{"*.jpg", "a.*"}, "a.png", false, true},
+		{"invalid pattern", []string{"["}, "a.png", false, false},
+	}
+
+	for _, test := range testdata {
+		t.Run(test.name, func(t *testing.T) {
+			if got := matchAny(test.patterns, test.rel, test.isDir); got != test.want {
+				t.Errorf("matchAny(%q, %q, %v) = %v, want %v",
+					test.patterns, test.rel, test.isDir, got, test.want)
+			}
+		})
+	}
+}
+
+// writeImage writes a png image to the path relative to root.
+func writeImage(t *testing.T, root, rel string) {
+	t.Helper()
+	path := filepath.Join(root, rel)
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		t.Fatal(err)
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	if err := png.Encode(file, image.NewRGBA(image.Rect(0, 0, 1, 1))); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFind(t *testing.T) {
+	root := t.TempDir()
+	for _, rel := range []string{
+		"a.png",
+		"b/b.png",
+		"b/c/c.png",
+		"b/c/d/d.png",
+		".hidden/e.png",
+		".f.png",
+		"drafts/g.png",
+		"ignored/h.png",
+		"ignored/i.png",
+	} {
+		writeImage(t, root, rel)
+	}
+	outside := t.TempDir()
+	writeImage(t, outside, "j.png")
+	if err := os.Symlink(outside, filepath.Join(root, "link")); err != nil {
+		t.Fatal(err)
+	}
+	// A loop is only walked once.
+	if err := os.Symlink(root, filepath.Join(outside, "loop")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "notes.txt"), []byte("notes"), 0o640); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "ignored", ignoreFile), []byte("# comment\nh.*\n"), 0o640); err != nil {
+		t.Fatal(err)
+	}
+
+	testdata := []struct {
+		name    string
+		options map[string]any
+		want    []string
+	}{
+		{"defaults", nil, []string{
+			"a.png", "b/b.png", "b/c/c.png", "b/c/d/d.png", "drafts/g.png", "ignored/i.png",
+		}},
+		{"hidden", map[string]any{"hidden": true}, []string{
+			".f.png", ".hidden/e.png", "a.png", "b/b.png", "b/c/c.png", "b/c/d/d.png",
+			"drafts/g.png", "ignored/i.png",
+		}},
+		{"max depth 1", map[string]any{"max-depth": 1}, []string{"a.png"}},
+		{"max depth 2", map[string]any{"max-depth": 2}, []string{
+			"a.png", "b/b.png", "drafts/g.png", "ignored/i.png",
+		}},
+		{"follow symlinks", map[string]any{"follow-symlinks": true}, []string{
+			"a.png", "b/b.png", "b/c/c.png", "b/c/d/d.png", "drafts/g.png", "ignored/i.png",
+			"link/j.png",
+		}},
+		{"exclude", map[string]any{"exclude": []string{"drafts/", "c"}}, []string{
+			"a.png", "b/b.png", "ignored/i.png",
+		}},
+	}
+
+	for _, test := range testdata {
+		t.Run(test.name, func(t *testing.T) {
+			store := config.New()
+			for key, value := range test.options {
+				store.Set(key, value)
+			}
+			ctx := config.WithStore(t.Context(), store)
+
+			paths := make(chan string)
+			var err error
+			go func() {
+				err = Find(ctx, []string{root}, paths)
+				close(paths)
+			}()
+
+			var got []string
+			for path := range paths {
+				rel, _ := filepath.Rel(root, path)
+				got = append(got, filepath.ToSlash(rel))
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !slices.Equal(got, test.want) {
+				t.Errorf("Find found %q, want %q", got, test.want)
+			}
+		})
+	}
+}
@@ -1,23 +1,24 @@
 package templates
 
 import (
+	"context"
 	"log/slog"
 	"slices"
 
+	"github.com/Nadim147c/rong/v5/internal/config"
 	"github.com/spf13/cast"
-	"github.com/spf13/viper"
 )
 
-func getConfig(key string) (map[string][]string, error) {
-	rawCfg := viper.Get(key)
+func getConfig(ctx context.Context, key string) (map[string][]string, error) {
+	rawCfg := config.Store(ctx).Get(key)
 	if rawCfg == nil {
 		return map[string][]string{}, nil // user has not specified config
 	}
 	return cast.ToStringMapStringSliceE(rawCfg)
 }
 
-func convertThemes(links, installs, cmds map[string][]string) {
-	themesCfg := viper.Get("themes")
+func convertThemes(ctx context.Context, links, installs, cmds map[string][]string) {
+	themesCfg := config.Store(ctx).Get("themes")
 	if themesCfg == nil {
 		return
 	}
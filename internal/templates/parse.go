@@ -0,0 +1,61 @@
+package templates
+
+import (
+	"embed"
+	"maps"
+	"os"
+	"sync"
+	"text/template"
+	"time"
+)
+
+//go:embed built-in/*.tmpl
+var templates embed.FS
+
+// parseBuiltIn parses the embedded templates once and reuses them afterwards.
+var parseBuiltIn = sync.OnceValues(func() (*template.Template, error) {
+	return template.New("").Funcs(funcs).ParseFS(templates, "built-in/*.tmpl")
+})
+
+// fileStat is used to detect changes of a template file.
+type fileStat struct {
+	size    int64
+	modTime time.Time
+}
+
+// userTemplates caches parsed user templates until any of the files change.
+var userTemplates struct {
+	sync.Mutex
+	stats map[string]fileStat
+	tmpl  *template.Template
+}
+
+// parseUser parses the user templates in paths. The parsed templates are
+// reused as long as the set of files and their size and modification time do
+// not change.
+func parseUser(paths []string) (*template.Template, error) {
+	stats := make(map[string]fileStat, len(paths))
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		stats[path] = fileStat{info.Size(), info.ModTime()}
+	}
+
+	userTemplates.Lock()
+	defer userTemplates.Unlock()
+
+	if userTemplates.tmpl != nil && maps.Equal(stats, userTemplates.stats) {
+		return userTemplates.tmpl, nil
+	}
+
+	tmpl, err := template.New("").Funcs(funcs).ParseFiles(paths...)
+	if err != nil {
+		return nil, err
+	}
+
+	userTemplates.stats = stats
+	userTemplates.tmpl = tmpl
+	return tmpl, nil
+}
@@ -3,7 +3,6 @@ package templates
 import (
 	"bytes"
 	"context"
-	"embed"
 	"errors"
 	"fmt"
 	"io"
@@ -21,13 +20,12 @@ import (
 	"unicode"
 
 	"github.com/MatusOllah/stripansi"
+	"github.com/Nadim147c/rong/v5/internal/config"
 	"github.com/Nadim147c/rong/v5/internal/models"
 	"github.com/Nadim147c/rong/v5/internal/pathutil"
-	"github.com/spf13/viper"
 )
 
-var success = successCounter{}
-
+// successCounter records templates written by a single execution.
 type successCounter map[string]struct{}
 
 func (s successCounter) set(n string) { s[n] = struct{}{} }
@@ -36,9 +34,6 @@ func (s successCounter) has(n string) bool {
 	return ok
 }
 
-//go:embed built-in/*.tmpl
-var templates embed.FS
-
 // ExecuteInline runs the given template without executing post hooks.
 func ExecuteInline(tmpl string, colors models.Output, w io.Writer) error {
 	inlineTemplate, err := template.New("").Funcs(funcs).Parse(tmpl)
@@ -48,19 +43,25 @@ func ExecuteInline(tmpl string, colors models.Output, w io.Writer) error {
 	return inlineTemplate.Execute(w, colors)
 }
 
+// executing is held while templates are executed, so concurrent executions
+// don't interleave writes of the same outputs.
+var executing sync.Mutex
+
 // Execute runs built-in and user-defined templates and links user defined
 // files.
 func Execute(ctx context.Context, colors models.Output) error {
+	executing.Lock()
+	defer executing.Unlock()
+
 	var allErrors []error
+	success := successCounter{}
 
 	if err := os.MkdirAll(pathutil.StateDir, 0o750); err != nil {
 		slog.Error("Failed to create app cache directory", "error", err)
 		return fmt.Errorf("failed to create state directory: %w", err)
 	}
 
-	defaultTmpl := template.New("").Funcs(funcs)
-
-	builtInTmpls, err := defaultTmpl.ParseFS(templates, "built-in/*.tmpl")
+	builtInTmpls, err := parseBuiltIn()
 	if err != nil {
 		slog.Error("Failed to parse templates", "error", err)
 		return fmt.Errorf("failed to parse built-in templates: %w", err)
@@ -68,7 +69,7 @@ func Execute(ctx context.Context, colors models.Output) error {
 
 	// Execute built-in templates and collect errors
 	for _, tmpl := range builtInTmpls.Templates() {
-		if err := execute(tmpl, colors); err != nil {
+		if err := execute(tmpl, colors, success); err != nil {
 			allErrors = append(allErrors, err)
 			slog.Error(
 				"Error executing built-in template",
@@ -95,19 +96,19 @@ func Execute(ctx context.Context, colors models.Output) error {
 	}
 
 	{
-		userTmpl := template.New("").Funcs(funcs)
-		userTmpls, err := userTmpl.ParseFiles(templatePaths...)
+		userTmpls, err := parseUser(templatePaths)
 		if err != nil {
 			slog.Error("Failed to parse user templates", "error", err)
 			allErrors = append(
 				allErrors,
 				fmt.Errorf("failed to parse user templates: %w", err),
 			)
+			goto hooks
 		}
 
 		// Execute user templates and collect errors
 		for tmpl := range slices.Values(userTmpls.Templates()) {
-			if err := execute(tmpl, colors); err != nil {
+			if err := execute(tmpl, colors, success); err != nil {
 				allErrors = append(allErrors, err)
 				slog.Error(
 					"Error executing user template",
@@ -123,7 +124,7 @@ func Execute(ctx context.Context, colors models.Output) error {
 hooks: // control flow easier to understand
 
 	// Run post-hook and collect any errors
-	postHookErrs := postHook(ctx, colors)
+	postHookErrs := postHook(ctx, colors, success)
 	if postHookErrs != nil {
 		allErrors = append(allErrors, postHookErrs)
 	}
@@ -139,17 +140,21 @@ hooks: // control flow easier to understand
 	return nil
 }
 
-func postHook(ctx context.Context, colors models.Output) error {
+func postHook(
+	ctx context.Context,
+	colors models.Output,
+	success successCounter,
+) error {
 	var allErrors []error
 
-	links, err := getConfig("links")
+	links, err := getConfig(ctx, "links")
 	if err != nil {
 		allErrors = append(
 			allErrors, fmt.Errorf("failed to parse links config: %w", err),
 		)
 	}
 
-	installs, err := getConfig("installs")
+	installs, err := getConfig(ctx, "installs")
 	if err != nil {
 		allErrors = append(
 			allErrors,
@@ -157,7 +162,7 @@ func postHook(ctx context.Context, colors models.Output) error {
 		)
 	}
 
-	newCmds, err := getConfig("cmds")
+	newCmds, err := getConfig(ctx, "cmds")
 	if err != nil {
 		allErrors = append(
 			allErrors, fmt.Errorf("failed to parse post_hooks config: %w", err),
@@ -165,7 +170,7 @@ func postHook(ctx context.Context, colors models.Output) error {
 	}
 
 	// DEPRECATED: use cmds instead.
-	cmds, err := getConfig("post-cmds")
+	cmds, err := getConfig(ctx, "post-cmds")
 	if err != nil {
 		allErrors = append(
 			allErrors, fmt.Errorf("failed to parse post-cmds config: %w", err),
@@ -176,7 +181,7 @@ func postHook(ctx context.Context, colors models.Output) error {
 	maps.Copy(cmds, newCmds)
 
 	// convert themes blocks simple config
-	convertThemes(links, installs, cmds)
+	convertThemes(ctx, links, installs, cmds)
 
 	exe, err := os.Executable()
 	if err != nil {
@@ -187,6 +192,7 @@ func postHook(ctx context.Context, colors models.Output) error {
 	}
 
 	// Base environment variables
+	store := config.Store(ctx)
 	baseEnv := os.Environ()
 	baseEnviron := map[string]string{
 		"RONG":        exe,
@@ -194,12 +200,12 @@ func postHook(ctx context.Context, colors models.Output) error {
 		"RONG_CONFIG": pathutil.ConfigDir,
 		"RONG_STATE":  pathutil.StateDir,
 		"IMAGE":       colors.Image,
-		"RONG_DARK":   viper.GetString("dark"),
+		"RONG_DARK":   store.GetString("dark"),
 		// material envs
-		"RONG_MATERIAL_VARIANT":  viper.GetString("material.variant"),
-		"RONG_MATERIAL_VERSION":  viper.GetString("material.version"),
-		"RONG_MATERIAL_CONTRAST": viper.GetString("material.contrast"),
-		"RONG_MATERIAL_PLATFORM": viper.GetString("material.platform"),
+		"RONG_MATERIAL_VARIANT":  store.GetString("material.variant"),
+		"RONG_MATERIAL_VERSION":  store.GetString("material.version"),
+		"RONG_MATERIAL_CONTRAST": store.GetString("material.contrast"),
+		"RONG_MATERIAL_PLATFORM": store.GetString("material.platform"),
 	}
 
 	var mu sync.Mutex
@@ -420,7 +426,11 @@ func link(src string, targets []string) ([]string, error) {
 }
 
 // execute executes a template using color and returns any error.
-func execute(tmpl *template.Template, out models.Output) error {
+func execute(
+	tmpl *template.Template,
+	out models.Output,
+	success successCounter,
+) error {
 	name := tmpl.Name()
 	filename := strings.TrimSuffix(name, ".tmpl")
 	outputPath := filepath.Join(pathutil.StateDir, filename)
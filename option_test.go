@@ -11,6 +11,7 @@ import (
 	"github.com/Nadim147c/rong/v5/internal/config"
 	"github.com/Nadim147c/rong/v5/internal/config/enums"
 	shlex "github.com/carapace-sh/carapace-shlex"
+	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
 )
 
@@ -62,3 +63,48 @@ func TestOptions(t *testing.T) {
 		})
 	}
 }
+
+func TestBoundFlags(t *testing.T) {
+	flags := config.Flags(icmd.Command.PersistentFlags(), func() *pflag.FlagSet {
+		set := pflag.NewFlagSet("test", pflag.ContinueOnError)
+		config.Dark.RegisterFlag(set)
+		config.MaterialContrast.RegisterFlag(set)
+		return set
+	}())
+
+	testdata := []struct {
+		name     string
+		args     []string
+		dark     bool
+		contrast float64
+		verbose  int
+	}{
+		{"defaults", nil, true, 0, 0},
+		{"light", []string{"--dark=false"}, false, 0, 0},
+		{"no dark", []string{"--no-dark"}, false, 0, 0},
+		{"contrast", []string{"--material.contrast", "0.5"}, true, 0.5, 0},
+		{"count", []string{"-vv"}, true, 0, 2},
+	}
+	for tt := range slices.Values(testdata) {
+		t.Run(tt.name, func(t *testing.T) {
+			global := viper.Get(config.MaterialContrast.Key())
+			store := config.New()
+			set := config.NewFlagSet("test", flags, store)
+			fatal(t, set.Parse(tt.args))
+
+			ctx := config.WithStore(t.Context(), store)
+			if got := config.Dark.Value(ctx); got != tt.dark {
+				t.Errorf("dark: want %v got %v", tt.dark, got)
+			}
+			if got := config.MaterialContrast.Value(ctx); got != tt.contrast {
+				t.Errorf("contrast: want %v got %v", tt.contrast, got)
+			}
+			if got := config.Verbose.Value(ctx); got != tt.verbose {
+				t.Errorf("verbose: want %v got %v", tt.verbose, got)
+			}
+			if viper.Get(config.MaterialContrast.Key()) != global {
+				t.Errorf("bound flag changed the global store")
+			}
+		})
+	}
+}